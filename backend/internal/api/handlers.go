@@ -1,6 +1,9 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"os"
 	"quotio-electron-go/backend/internal/agents"
@@ -8,9 +11,11 @@ import (
 	"quotio-electron-go/backend/internal/proxy"
 	"quotio-electron-go/backend/internal/storage"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func (s *Server) handleHealth(c *gin.Context) {
@@ -53,6 +58,12 @@ func (s *Server) handleDashboard(c *gin.Context) {
 		successRate = float64(successToday) / float64(totalToday)
 	}
 
+	// Requests answered from the response cache today
+	var cacheHitsToday int64
+	s.db.Model(&storage.QuotaHistory{}).
+		Where("timestamp >= ? AND cached = ?", startOfDay, true).
+		Count(&cacheHitsToday)
+
 	// Compute provider-level counts for badges
 	type ProviderSummary struct {
 		Provider string `json:"provider"`
@@ -76,6 +87,7 @@ func (s *Server) handleDashboard(c *gin.Context) {
 		"requests_today":  todayRequests,
 		"tokens_today":    tokensToday,
 		"success_rate":    successRate,
+		"cache_hits_today": cacheHitsToday,
 		"providers":       perProvider,
 		"uptime":          time.Since(time.Now()).String(), // Simplified
 	})
@@ -556,9 +568,17 @@ func (s *Server) handleStopProxy(c *gin.Context) {
 
 func (s *Server) handleProxyStatus(c *gin.Context) {
 	running := s.proxy != nil && s.proxy.IsRunning()
+	port, queueDepth := s.config.ProxyPort, 0
+	if s.proxy != nil {
+		port = s.proxy.Port()
+	}
+	if running {
+		queueDepth = s.proxy.QueueDepth()
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"running": running,
-		"port":    s.config.ProxyPort,
+		"running":     running,
+		"port":        port,
+		"queue_depth": queueDepth,
 	})
 }
 
@@ -584,7 +604,25 @@ func (s *Server) handleUpdateSettings(c *gin.Context) {
 		return
 	}
 
-	if err := s.db.Save(&proxyConfig).Error; err != nil {
+	if proxyConfig.Port < 1 || proxyConfig.Port > 65535 || proxyConfig.Port == s.config.Port {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proxy port: " + strconv.Itoa(proxyConfig.Port)})
+		return
+	}
+	if !proxy.IsValidStrategy(proxyConfig.RoutingStrategy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy. Use one of: " + strings.Join(proxy.RoutingStrategies, ", ")})
+		return
+	}
+
+	// A port that can't be bound, or a failed save, leaves both the proxy and
+	// the saved settings as they were
+	err := s.reconfigureProxy(proxyConfig, func() error {
+		return s.db.Save(&proxyConfig).Error
+	})
+	if errors.Is(err, proxy.ErrPortUnavailable) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -595,6 +633,16 @@ func (s *Server) handleUpdateSettings(c *gin.Context) {
 	c.JSON(http.StatusOK, proxyConfig)
 }
 
+// reconfigureProxy persists proxy settings through save and, if the proxy has
+// been created, applies them to it. A running proxy binds a changed port before
+// save runs and only switches over once it succeeds.
+func (s *Server) reconfigureProxy(proxyConfig storage.ProxyConfig, save func() error) error {
+	if s.proxy == nil {
+		return save()
+	}
+	return s.proxy.Reconfigure(proxyConfig, save)
+}
+
 func (s *Server) handleGetProviderHealth(c *gin.Context) {
 	var healths []storage.ProviderHealth
 	if err := s.db.Find(&healths).Error; err != nil {
@@ -651,7 +699,6 @@ func (s *Server) handleCheckProviderHealth(c *gin.Context) {
 	health := storage.ProviderHealth{
 		AccountID:           account.ID,
 		IsHealthy:           result.IsValid,
-		ResponseTime:        0, // Could measure if needed
 		LastChecked:         now,
 		ConsecutiveFailures: 0,
 	}
@@ -664,7 +711,6 @@ func (s *Server) handleCheckProviderHealth(c *gin.Context) {
 	} else {
 		// Update existing record
 		existingHealth.IsHealthy = result.IsValid
-		existingHealth.ResponseTime = 0
 		existingHealth.LastChecked = now
 		if !result.IsValid {
 			existingHealth.ConsecutiveFailures++
@@ -804,7 +850,8 @@ func (s *Server) handleGetModels(c *gin.Context) {
 
 func (s *Server) handleUpdateRoutingStrategy(c *gin.Context) {
 	var req struct {
-		Strategy string `json:"strategy" binding:"required"`
+		Strategy string       `json:"strategy" binding:"required"`
+		Weights  map[uint]int `json:"weights"` // Optional account ID -> weight for the weighted strategy
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -813,11 +860,23 @@ func (s *Server) handleUpdateRoutingStrategy(c *gin.Context) {
 	}
 
 	// Validate strategy
-	if req.Strategy != "round_robin" && req.Strategy != "fill_first" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy. Use 'round_robin' or 'fill_first'"})
+	if !proxy.IsValidStrategy(req.Strategy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid strategy. Use one of: " + strings.Join(proxy.RoutingStrategies, ", ")})
 		return
 	}
 
+	for id, weight := range req.Weights {
+		if weight < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Weights must not be negative"})
+			return
+		}
+		var account storage.Account
+		if err := s.db.Select("id").First(&account, id).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Account not found: " + strconv.FormatUint(uint64(id), 10)})
+			return
+		}
+	}
+
 	var proxyConfig storage.ProxyConfig
 	if err := s.db.First(&proxyConfig).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -825,7 +884,17 @@ func (s *Server) handleUpdateRoutingStrategy(c *gin.Context) {
 	}
 
 	proxyConfig.RoutingStrategy = req.Strategy
-	if err := s.db.Save(&proxyConfig).Error; err != nil {
+	err := s.reconfigureProxy(proxyConfig, func() error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			for id, weight := range req.Weights {
+				if err := tx.Model(&storage.Account{}).Where("id = ?", id).Update("weight", weight).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Save(&proxyConfig).Error
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -858,6 +927,14 @@ func (s *Server) handleGetRateLimits(c *gin.Context) {
 		CooldownUntil          time.Time `json:"cooldown_until,omitempty"`
 		ResponseTime           int64     `json:"response_time_ms"`
 		LastChecked            string    `json:"last_checked"`
+		MaxConcurrent          int       `json:"max_concurrent"`
+		InFlight               int       `json:"in_flight"`
+	}
+
+	// Live concurrency is only known while the proxy runs
+	inFlight := map[uint]int{}
+	if s.proxy != nil && s.proxy.IsRunning() {
+		inFlight = s.proxy.InFlight()
 	}
 
 	result := make([]RateLimitStatus, 0)
@@ -895,6 +972,8 @@ func (s *Server) handleGetRateLimits(c *gin.Context) {
 			CooldownUntil:     account.CooldownUntil,
 			ResponseTime:      responseTime,
 			LastChecked:       lastChecked,
+			MaxConcurrent:     account.MaxConcurrent,
+			InFlight:          inFlight[account.ID],
 		})
 	}
 
@@ -1007,3 +1086,576 @@ func (s *Server) handleAddProviderFromOAuth(c *gin.Context) {
 		"account": account.ID,
 	})
 }
+
+// clientKeyRequest is the body for creating a client key
+type clientKeyRequest struct {
+	Name               string   `json:"name" binding:"required"`
+	AllowedProviders   []string `json:"allowed_providers"`
+	AllowedModels      []string `json:"allowed_models"`
+	RPM                int      `json:"rpm"`
+	DailyTokenBudget   int64    `json:"daily_token_budget"`
+	MonthlyTokenBudget int64    `json:"monthly_token_budget"`
+}
+
+func (s *Server) handleGetClientKeys(c *gin.Context) {
+	var keys []storage.ClientKey
+	if err := s.db.Order("id").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Include usage against the budgets
+	type ClientKeyWithUsage struct {
+		storage.ClientKey
+		TokensToday     int64 `json:"tokens_today"`
+		TokensThisMonth int64 `json:"tokens_this_month"`
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	result := make([]ClientKeyWithUsage, 0, len(keys))
+	for _, key := range keys {
+		tokensToday, _ := storage.GetClientKeyTokensSince(key.ID, today)
+		tokensThisMonth, _ := storage.GetClientKeyTokensSince(key.ID, month)
+		result = append(result, ClientKeyWithUsage{
+			ClientKey:       key,
+			TokensToday:     tokensToday,
+			TokensThisMonth: tokensThisMonth,
+		})
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (s *Server) handleCreateClientKey(c *gin.Context) {
+	var req clientKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.RPM < 0 || req.DailyTokenBudget < 0 || req.MonthlyTokenBudget < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Limits must not be negative"})
+		return
+	}
+	for _, name := range req.AllowedProviders {
+		if providers.GetProvider(name) == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown provider: " + name})
+			return
+		}
+	}
+
+	secret, err := storage.GenerateClientKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := storage.ClientKey{
+		Name:               strings.TrimSpace(req.Name),
+		KeyHash:            storage.HashClientKey(secret),
+		KeyPrefix:          storage.ClientKeyDisplayPrefix(secret),
+		AllowedProviders:   encodeList(req.AllowedProviders),
+		AllowedModels:      encodeList(req.AllowedModels),
+		RPM:                req.RPM,
+		DailyTokenBudget:   req.DailyTokenBudget,
+		MonthlyTokenBudget: req.MonthlyTokenBudget,
+	}
+	if err := s.db.Create(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.invalidateClientKeys()
+
+	// The plaintext key is only ever returned here and on rotation
+	c.JSON(http.StatusCreated, gin.H{"key": secret, "client_key": key})
+}
+
+func (s *Server) handleRevokeClientKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var key storage.ClientKey
+	if err := s.db.First(&key, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Client key not found"})
+		return
+	}
+
+	if !key.Revoked {
+		now := time.Now()
+		key.Revoked = true
+		key.RevokedAt = &now
+		if err := s.db.Save(&key).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		s.invalidateClientKeys()
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+func (s *Server) handleRotateClientKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var key storage.ClientKey
+	if err := s.db.First(&key, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Client key not found"})
+		return
+	}
+	if key.Revoked {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Client key is revoked"})
+		return
+	}
+
+	secret, err := storage.GenerateClientKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The old key stops working immediately; scopes, limits and usage history carry over
+	key.KeyHash = storage.HashClientKey(secret)
+	key.KeyPrefix = storage.ClientKeyDisplayPrefix(secret)
+	if err := s.db.Save(&key).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.invalidateClientKeys()
+
+	c.JSON(http.StatusOK, gin.H{"key": secret, "client_key": key})
+}
+
+// invalidateClientKeys makes a running proxy pick up client key changes
+func (s *Server) invalidateClientKeys() {
+	if s.proxy != nil {
+		s.proxy.InvalidateClientKeys()
+	}
+}
+
+// invalidateModelAliases makes a running proxy pick up model alias changes
+func (s *Server) invalidateModelAliases() {
+	if s.proxy != nil {
+		s.proxy.InvalidateModelAliases()
+	}
+}
+
+// invalidateFallbackChains makes a running proxy pick up fallback chain changes
+func (s *Server) invalidateFallbackChains() {
+	if s.proxy != nil {
+		s.proxy.InvalidateFallbackChains()
+	}
+}
+
+// encodeList stores a list of names as a JSON array, or "" when it's empty
+func encodeList(items []string) string {
+	cleaned := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			cleaned = append(cleaned, item)
+		}
+	}
+	if len(cleaned) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(cleaned)
+	return string(data)
+}
+
+func (s *Server) handleGetCaptures(c *gin.Context) {
+	limit := 50 // Default limit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	// Bodies and headers are only returned when inspecting a single capture
+	query := s.db.Model(&storage.Capture{}).
+		Omit("request_headers", "request_body", "response_headers", "response_body").
+		Order("id DESC").
+		Limit(limit)
+	if accountID := c.Query("account_id"); accountID != "" {
+		query = query.Where("account_id = ?", accountID)
+	}
+	if model := c.Query("model"); model != "" {
+		query = query.Where("model = ?", model)
+	}
+	if c.Query("failed") == "true" {
+		query = query.Where("status_code >= ? OR error != ''", 400)
+	}
+
+	var captures []storage.Capture
+	if err := query.Find(&captures).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, captures)
+}
+
+func (s *Server) handleGetCapture(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var capture storage.Capture
+	if err := s.db.First(&capture, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Capture not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, capture)
+}
+
+func (s *Server) handleReplayCapture(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var req struct {
+		AccountID uint `json:"account_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var capture storage.Capture
+	if err := s.db.First(&capture, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Capture not found"})
+		return
+	}
+
+	var account storage.Account
+	if err := s.db.First(&account, req.AccountID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	started := time.Now()
+	resp, err := proxy.ReplayCapture(&capture, &account)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, proxy.ErrReplayIncompatible) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4*1024*1024))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"capture_id":  capture.ID,
+		"account_id":  account.ID,
+		"status_code": resp.StatusCode,
+		"headers":     resp.Header,
+		"body":        string(body),
+		"duration_ms": time.Since(started).Milliseconds(),
+	})
+}
+
+func (s *Server) handleClearCaptures(c *gin.Context) {
+	if err := s.db.Where("1 = 1").Delete(&storage.Capture{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Captures cleared"})
+}
+
+type modelAliasRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Models      []string `json:"models" binding:"required"`
+	Description string   `json:"description"`
+}
+
+// validateModelAlias checks an alias definition, returning a message for the client if it's invalid
+func validateModelAlias(req *modelAliasRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "Alias name is required"
+	}
+	if _, ok := providers.GetModel(req.Name, ""); ok {
+		return "Alias name must not shadow the model " + req.Name
+	}
+	if encodeList(req.Models) == "" {
+		return "An alias needs at least one target model"
+	}
+	for _, model := range req.Models {
+		if strings.TrimSpace(model) == req.Name {
+			return "An alias can't target itself"
+		}
+	}
+	return ""
+}
+
+func (s *Server) handleGetModelAliases(c *gin.Context) {
+	var aliases []storage.ModelAlias
+	if err := s.db.Order("name").Find(&aliases).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aliases)
+}
+
+func (s *Server) handleCreateModelAlias(c *gin.Context) {
+	var req modelAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateModelAlias(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if existing, err := storage.FindModelAlias(req.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if existing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alias already exists: " + req.Name})
+		return
+	}
+
+	alias := storage.ModelAlias{
+		Name:        req.Name,
+		Models:      encodeList(req.Models),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if err := s.db.Create(&alias).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.invalidateModelAliases()
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+func (s *Server) handleUpdateModelAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var alias storage.ModelAlias
+	if err := s.db.First(&alias, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias not found"})
+		return
+	}
+
+	var req modelAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateModelAlias(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if existing, err := storage.FindModelAlias(req.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if existing != nil && existing.ID != alias.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Alias already exists: " + req.Name})
+		return
+	}
+
+	alias.Name = req.Name
+	alias.Models = encodeList(req.Models)
+	alias.Description = strings.TrimSpace(req.Description)
+	if err := s.db.Save(&alias).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.invalidateModelAliases()
+
+	c.JSON(http.StatusOK, alias)
+}
+
+func (s *Server) handleDeleteModelAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	result := s.db.Delete(&storage.ModelAlias{}, uint(id))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alias not found"})
+		return
+	}
+
+	s.invalidateModelAliases()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alias deleted"})
+}
+
+type fallbackChainRequest struct {
+	Model     string   `json:"model" binding:"required"`
+	Fallbacks []string `json:"fallbacks" binding:"required"`
+}
+
+// validateFallbackChain checks that the chain only names catalog models, returning a message for the client if it doesn't
+func validateFallbackChain(req *fallbackChainRequest) string {
+	req.Model = strings.TrimSpace(req.Model)
+	if _, ok := providers.GetModel(req.Model, ""); !ok {
+		return "Unknown model: " + req.Model
+	}
+	if encodeList(req.Fallbacks) == "" {
+		return "A fallback chain needs at least one model"
+	}
+
+	seen := map[string]bool{req.Model: true}
+	for _, model := range req.Fallbacks {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+		if _, ok := providers.GetModel(model, ""); !ok {
+			return "Unknown model: " + model
+		}
+		if seen[model] {
+			return "Model listed more than once in the chain: " + model
+		}
+		seen[model] = true
+	}
+	return ""
+}
+
+func (s *Server) handleGetFallbackChains(c *gin.Context) {
+	var chains []storage.FallbackChain
+	if err := s.db.Order("model").Find(&chains).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, chains)
+}
+
+func (s *Server) handleCreateFallbackChain(c *gin.Context) {
+	var req fallbackChainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateFallbackChain(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if existing, err := storage.FindFallbackChain(req.Model); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if existing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A fallback chain already exists for " + req.Model})
+		return
+	}
+
+	chain := storage.FallbackChain{
+		Model:     req.Model,
+		Fallbacks: encodeList(req.Fallbacks),
+	}
+	if err := s.db.Create(&chain).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.invalidateFallbackChains()
+
+	c.JSON(http.StatusCreated, chain)
+}
+
+func (s *Server) handleUpdateFallbackChain(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var chain storage.FallbackChain
+	if err := s.db.First(&chain, uint(id)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fallback chain not found"})
+		return
+	}
+
+	var req fallbackChainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateFallbackChain(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	if existing, err := storage.FindFallbackChain(req.Model); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	} else if existing != nil && existing.ID != chain.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "A fallback chain already exists for " + req.Model})
+		return
+	}
+
+	chain.Model = req.Model
+	chain.Fallbacks = encodeList(req.Fallbacks)
+	if err := s.db.Save(&chain).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.invalidateFallbackChains()
+
+	c.JSON(http.StatusOK, chain)
+}
+
+func (s *Server) handleDeleteFallbackChain(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	result := s.db.Delete(&storage.FallbackChain{}, uint(id))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fallback chain not found"})
+		return
+	}
+
+	s.invalidateFallbackChains()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fallback chain deleted"})
+}
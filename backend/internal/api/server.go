@@ -68,11 +68,31 @@ func (s *Server) setupRoutes() {
 	api.GET("/quota/failed", s.handleGetFailedRequests)
 	api.POST("/quota/reset/:id", s.handleResetQuota)
 	api.GET("/models", s.handleGetModels)
+	api.GET("/models/aliases", s.handleGetModelAliases)
+	api.POST("/models/aliases", s.handleCreateModelAlias)
+	api.PUT("/models/aliases/:id", s.handleUpdateModelAlias)
+	api.DELETE("/models/aliases/:id", s.handleDeleteModelAlias)
+	api.GET("/models/fallbacks", s.handleGetFallbackChains)
+	api.POST("/models/fallbacks", s.handleCreateFallbackChain)
+	api.PUT("/models/fallbacks/:id", s.handleUpdateFallbackChain)
+	api.DELETE("/models/fallbacks/:id", s.handleDeleteFallbackChain)
 
 	// Routing
 	api.POST("/routing-strategy", s.handleUpdateRoutingStrategy)
 	api.GET("/rate-limits", s.handleGetRateLimits)
 
+	// Client API keys
+	api.GET("/keys", s.handleGetClientKeys)
+	api.POST("/keys", s.handleCreateClientKey)
+	api.POST("/keys/:id/revoke", s.handleRevokeClientKey)
+	api.POST("/keys/:id/rotate", s.handleRotateClientKey)
+
+	// Captures
+	api.GET("/captures", s.handleGetCaptures)
+	api.GET("/captures/:id", s.handleGetCapture)
+	api.POST("/captures/:id/replay", s.handleReplayCapture)
+	api.DELETE("/captures", s.handleClearCaptures)
+
 	// OAuth Detection
 	api.GET("/providers/detect-oauth", s.handleDetectOAuthCredentials)
 	api.POST("/providers/from-oauth", s.handleAddProviderFromOAuth)
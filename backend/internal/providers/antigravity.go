@@ -20,6 +20,11 @@ func NewAntigravityProvider() *AntigravityProvider {
 	}
 }
 
+// GetAPIFormat returns the Google generateContent format (same gateway as Gemini)
+func (p *AntigravityProvider) GetAPIFormat() string {
+	return APIFormatGemini
+}
+
 func (p *AntigravityProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	// Prefer OAuth token (for cloud proxy)
 	if account.OAuthToken != "" {
@@ -18,6 +18,11 @@ func NewClaudeProvider() *ClaudeProvider {
 	}
 }
 
+// GetAPIFormat returns the Anthropic Messages format
+func (p *ClaudeProvider) GetAPIFormat() string {
+	return APIFormatAnthropic
+}
+
 func (p *ClaudeProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	// Prefer OAuth token over API key (Claude Code uses OAuth)
 	if account.OAuthToken != "" {
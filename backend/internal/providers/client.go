@@ -1,6 +1,7 @@
 package providers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -8,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"quotio-electron-go/backend/internal/storage"
+	"strings"
 	"time"
 )
 
@@ -190,9 +192,15 @@ func (pc *ProviderClient) ParseRateLimitsFromResponse(resp *http.Response) (*Rat
 	// Parse headers
 	info := parseProviderRateLimits(pc.account.Provider, resp.Header)
 
-	// If no rate limit headers found, try parsing from response body
-	if info.TokensLimit == 0 && info.RequestsLimit == 0 {
+	// If no rate limit headers found, try parsing from response body.
+	// Event streams are left alone: reading them here would hold back the
+	// whole stream until it finished.
+	isStream := strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream")
+	if info.TokensLimit == 0 && info.RequestsLimit == 0 && !isStream {
 		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		// Restore the body so the response can still be sent to the client
+		resp.Body = io.NopCloser(bytes.NewReader(body))
 		if err == nil {
 			info = parseRateLimitsFromBody(pc.account.Provider, body)
 		}
@@ -19,6 +19,11 @@ func NewGeminiProvider() *GeminiProvider {
 	}
 }
 
+// GetAPIFormat returns the Google generateContent format
+func (p *GeminiProvider) GetAPIFormat() string {
+	return APIFormatGemini
+}
+
 func (p *GeminiProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	// Prefer OAuth token (Gemini CLI uses OAuth)
 	if account.OAuthToken != "" {
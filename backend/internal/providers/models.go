@@ -1,5 +1,7 @@
 package providers
 
+import "strings"
+
 type ModelInfo struct {
 	ID          string   `json:"id"`
 	Name        string   `json:"name"`
@@ -101,6 +103,22 @@ var SupportedModels = []ModelInfo{
 		ContextWindow: 128000,
 	},
 
+	// Z.ai
+	{
+		ID:            "glm-4.6",
+		Name:          "GLM-4.6",
+		Provider:      "z.ai",
+		Description:   "Zhipu's flagship coding and agent model.",
+		ContextWindow: 200000,
+	},
+	{
+		ID:            "glm-4.5-air",
+		Name:          "GLM-4.5 Air",
+		Provider:      "z.ai",
+		Description:   "Lightweight, fast GLM model.",
+		ContextWindow: 128000,
+	},
+
 	// Cursor
 	{
 		ID:            "claude-3-5-sonnet",
@@ -134,3 +152,110 @@ func GetModelsByProvider(provider string) []ModelInfo {
 	}
 	return results
 }
+
+// DefaultModelForProvider returns the provider's first catalog model, used when a
+// request has to be served by a provider that doesn't know the requested model
+func DefaultModelForProvider(provider string) string {
+	for _, m := range SupportedModels {
+		if m.Provider == provider {
+			return m.ID
+		}
+	}
+	return ""
+}
+
+// modelPrefixProviders maps well-known model ID prefixes to the providers that
+// natively serve them. Used for models that aren't listed in SupportedModels
+// (e.g. dated snapshots like claude-3-5-sonnet-20241022).
+var modelPrefixProviders = []struct {
+	Prefix    string
+	Providers []string
+}{
+	{Prefix: "claude-", Providers: []string{"claude"}},
+	{Prefix: "gpt-", Providers: []string{"openai"}},
+	{Prefix: "o1", Providers: []string{"openai"}},
+	{Prefix: "o3", Providers: []string{"openai"}},
+	{Prefix: "o4", Providers: []string{"openai"}},
+	{Prefix: "codex-", Providers: []string{"openai"}},
+	{Prefix: "gemini-", Providers: []string{"gemini", "vertex"}},
+	{Prefix: "qwen", Providers: []string{"qwen"}},
+	{Prefix: "glm-", Providers: []string{"z.ai"}},
+}
+
+// GetModel returns the catalog entry for a model ID, preferring the given
+// provider when the same ID is served by several providers
+func GetModel(modelID string, provider string) (ModelInfo, bool) {
+	var found ModelInfo
+	ok := false
+	for _, m := range SupportedModels {
+		if m.ID != modelID {
+			continue
+		}
+		if provider == "" || m.Provider == provider {
+			return m, true
+		}
+		if !ok {
+			found, ok = m, true
+		}
+	}
+	return found, ok
+}
+
+// GetProvidersForModel returns the providers able to serve a model ID: those
+// listing it in the catalog, plus those serving its well-known prefix (so
+// catalog Gemini IDs are served by vertex as well as gemini)
+func GetProvidersForModel(modelID string) []string {
+	var results []string
+	seen := make(map[string]bool)
+	for _, m := range SupportedModels {
+		if m.ID == modelID && !seen[m.Provider] {
+			seen[m.Provider] = true
+			results = append(results, m.Provider)
+		}
+	}
+
+	lower := strings.ToLower(modelID)
+	for _, p := range modelPrefixProviders {
+		if !strings.HasPrefix(lower, p.Prefix) {
+			continue
+		}
+		for _, provider := range p.Providers {
+			if !seen[provider] {
+				seen[provider] = true
+				results = append(results, provider)
+			}
+		}
+	}
+	return results
+}
+
+// OfferedModels returns the catalog models a provider offers: its own entries,
+// or for a provider without any (vertex, ...) the catalog models whose ID
+// prefix modelPrefixProviders maps to it
+func OfferedModels(provider string) []ModelInfo {
+	if models := GetModelsByProvider(provider); len(models) > 0 {
+		return models
+	}
+
+	var results []ModelInfo
+	seen := make(map[string]bool)
+	for _, m := range SupportedModels {
+		if seen[m.ID] || !ProviderServesModel(provider, m.ID) {
+			continue
+		}
+		seen[m.ID] = true
+		m.Provider = provider
+		results = append(results, m)
+	}
+	return results
+}
+
+// ProviderServesModel reports whether a provider can serve a model ID
+func ProviderServesModel(provider string, modelID string) bool {
+	for _, p := range GetProvidersForModel(modelID) {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
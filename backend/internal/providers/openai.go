@@ -19,6 +19,11 @@ func NewOpenAIProvider() *OpenAIProvider {
 	}
 }
 
+// GetExtraAPIFormats reports native Responses API support alongside Chat Completions
+func (p *OpenAIProvider) GetExtraAPIFormats() []string {
+	return []string{APIFormatResponses}
+}
+
 func (p *OpenAIProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	// Prefer OAuth token over API key (Codex uses OAuth)
 	if account.OAuthToken != "" {
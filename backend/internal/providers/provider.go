@@ -7,9 +7,40 @@ import (
 	"quotio-electron-go/backend/internal/storage"
 )
 
+// API formats spoken by upstream providers
+const (
+	APIFormatAnthropic = "anthropic"        // Anthropic Messages (/v1/messages)
+	APIFormatOpenAI    = "openai"           // OpenAI Chat Completions
+	APIFormatResponses = "openai-responses" // OpenAI Responses (/v1/responses)
+	APIFormatGemini    = "gemini"           // Google generateContent
+)
+
+// multiFormatProvider is implemented by providers that natively speak more
+// than their primary API format
+type multiFormatProvider interface {
+	GetExtraAPIFormats() []string
+}
+
+// SupportsAPIFormat reports whether a provider serves an API format without translation
+func SupportsAPIFormat(p Provider, format string) bool {
+	if p.GetAPIFormat() == format {
+		return true
+	}
+	if mp, ok := p.(multiFormatProvider); ok {
+		for _, f := range mp.GetExtraAPIFormats() {
+			if f == format {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type Provider interface {
 	GetName() string
 	GetBaseURL() string
+	GetAPIFormat() string               // API format the upstream speaks natively
+	GetUpstreamPath(path string) string // Maps a client-facing path to the upstream path
 	AuthenticateRequest(req *http.Request, account *storage.Account) error
 	ParseQuotaFromResponse(resp *http.Response) (int64, error) // Parse from headers only
 	ParseQuotaFromBody(body []byte) (int64, error)              // Parse quota from buffered body
@@ -31,6 +62,16 @@ func (p *BaseProvider) GetBaseURL() string {
 	return p.BaseURL
 }
 
+// GetAPIFormat defaults to OpenAI-compatible, which most providers speak
+func (p *BaseProvider) GetAPIFormat() string {
+	return APIFormatOpenAI
+}
+
+// GetUpstreamPath defaults to forwarding the path unchanged
+func (p *BaseProvider) GetUpstreamPath(path string) string {
+	return path
+}
+
 func (p *BaseProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	if account.APIKey != "" {
 		req.Header.Set("Authorization", "Bearer "+account.APIKey)
@@ -64,6 +105,19 @@ func (p *BaseProvider) ParseQuotaFromBody(body []byte) (int64, error) {
 		if tokens, ok := usage["total_tokens"].(float64); ok {
 			return int64(tokens), nil
 		}
+		// Anthropic reports input and output separately
+		input, hasInput := usage["input_tokens"].(float64)
+		output, hasOutput := usage["output_tokens"].(float64)
+		if hasInput || hasOutput {
+			return int64(input + output), nil
+		}
+	}
+
+	// Gemini generateContent
+	if usage, ok := data["usageMetadata"].(map[string]interface{}); ok {
+		if tokens, ok := usage["totalTokenCount"].(float64); ok {
+			return int64(tokens), nil
+		}
 	}
 
 	return 0, nil
@@ -3,6 +3,7 @@ package providers
 import (
 	"net/http"
 	"quotio-electron-go/backend/internal/storage"
+	"strings"
 )
 
 type QwenProvider struct {
@@ -18,6 +19,14 @@ func NewQwenProvider() *QwenProvider {
 	}
 }
 
+// GetUpstreamPath routes OpenAI-style paths to DashScope's compatible mode
+func (p *QwenProvider) GetUpstreamPath(path string) string {
+	if strings.HasPrefix(path, "/v1/") {
+		return "/compatible-mode" + path
+	}
+	return path
+}
+
 func (p *QwenProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	// Prefer OAuth token (Qwen Code uses OAuth)
 	if account.OAuthToken != "" {
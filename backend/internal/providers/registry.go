@@ -45,6 +45,37 @@ func GetProviderForAccount(account *storage.Account) Provider {
 	return GetProvider(account.Provider)
 }
 
+// AccountServesModel reports whether an account can serve a model ID.
+// An explicit ModelAccess list always wins over the provider catalog. Providers
+// with no catalog entries only serve the model prefixes mapped to them, so
+// their accounts need a ModelAccess list for anything else.
+func AccountServesModel(account *storage.Account, modelID string) bool {
+	if modelID == "" {
+		return true
+	}
+
+	if allowed := account.AllowedModels(); len(allowed) > 0 {
+		for _, m := range allowed {
+			if m == modelID {
+				return true
+			}
+		}
+		return false
+	}
+
+	return ProviderServesModel(account.Provider, modelID)
+}
+
+// DefaultModelForAccount returns the model to substitute when an account serves
+// a request for a model it doesn't offer: the first allowed model, else the
+// provider's default
+func DefaultModelForAccount(account *storage.Account) string {
+	if allowed := account.AllowedModels(); len(allowed) > 0 {
+		return allowed[0]
+	}
+	return DefaultModelForProvider(account.Provider)
+}
+
 // CreateProviderClient creates a provider client for a given account
 func CreateProviderClient(account *storage.Account) (*ProviderClient, error) {
 	return NewProviderClient(account)
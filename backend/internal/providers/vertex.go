@@ -3,8 +3,12 @@ package providers
 import (
 	"net/http"
 	"quotio-electron-go/backend/internal/storage"
+	"regexp"
 )
 
+// vertexModelPath matches Gemini API model paths such as /v1beta/models/gemini-2.0-flash:generateContent
+var vertexModelPath = regexp.MustCompile(`^/v1(?:beta|alpha)?/models/([^/]+)$`)
+
 type VertexProvider struct {
 	BaseProvider
 }
@@ -18,6 +22,19 @@ func NewVertexProvider() *VertexProvider {
 	}
 }
 
+// GetAPIFormat returns the Google generateContent format
+func (p *VertexProvider) GetAPIFormat() string {
+	return APIFormatGemini
+}
+
+// GetUpstreamPath maps Gemini API model paths onto Vertex AI publisher model paths
+func (p *VertexProvider) GetUpstreamPath(path string) string {
+	if m := vertexModelPath.FindStringSubmatch(path); m != nil {
+		return "/v1/publishers/google/models/" + m[1]
+	}
+	return path
+}
+
 func (p *VertexProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	// Vertex AI uses OAuth tokens (Google Cloud)
 	if account.OAuthToken != "" {
@@ -3,6 +3,7 @@ package providers
 import (
 	"net/http"
 	"quotio-electron-go/backend/internal/storage"
+	"strings"
 )
 
 type ZAIProvider struct {
@@ -18,6 +19,11 @@ func NewZAIProvider() *ZAIProvider {
 	}
 }
 
+// GetUpstreamPath maps /v1/... onto the /api/paas/v4 prefix of the base URL
+func (p *ZAIProvider) GetUpstreamPath(path string) string {
+	return "/api/paas/v4" + strings.TrimPrefix(path, "/v1")
+}
+
 func (p *ZAIProvider) AuthenticateRequest(req *http.Request, account *storage.Account) error {
 	if account.OAuthToken != "" {
 		req.Header.Set("Authorization", "Bearer "+account.OAuthToken)
@@ -0,0 +1,317 @@
+package proxy
+
+import (
+	"errors"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"quotio-electron-go/backend/internal/storage"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// statsAlpha is the weight of the newest sample in each moving average
+	statsAlpha = 0.2
+	// errorHalfLife is how long a recorded error rate takes to fade by half when
+	// the account sees no traffic, so a flaky account is eventually retried
+	errorHalfLife = 5 * time.Minute
+	// minAdaptiveScore keeps every eligible account in rotation at a trickle
+	minAdaptiveScore = 0.02
+	// healthFlushInterval is how often changed response times are written to
+	// ProviderHealth, so at most one write per account per interval
+	healthFlushInterval = 30 * time.Second
+)
+
+// accountStats holds exponentially weighted performance figures for one account
+type accountStats struct {
+	HeaderLatency float64 // ms until response headers arrived
+	FirstByte     float64 // ms until the first body byte arrived
+	ErrorRate     float64 // Share of recent attempts that failed, 0..1
+	Samples       int
+	Updated       time.Time
+}
+
+// healthStats tracks live latency and error rates per account for the adaptive strategy
+type healthStats struct {
+	mu       sync.Mutex
+	accounts map[uint]*accountStats
+	dirty    map[uint]bool // Accounts whose time to first byte changed since the last flush
+}
+
+func newHealthStats() *healthStats {
+	return &healthStats{accounts: make(map[uint]*accountStats), dirty: make(map[uint]bool)}
+}
+
+// seed starts the latency averages from the response times persisted by earlier runs
+func (h *healthStats) seed(db *gorm.DB) {
+	var healths []storage.ProviderHealth
+	if err := db.Where("response_time > 0").Find(&healths).Error; err != nil {
+		log.Printf("Failed to load provider response times: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, health := range healths {
+		ms := float64(health.ResponseTime)
+		h.accounts[health.AccountID] = &accountStats{HeaderLatency: ms, FirstByte: ms, Updated: health.LastChecked}
+	}
+}
+
+// observe records the outcome of one upstream attempt. A zero latency means the
+// attempt never got response headers.
+func (h *healthStats) observe(accountID uint, latency time.Duration, failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := h.get(accountID)
+	stats.ErrorRate = ewma(stats.decayedErrorRate(time.Now()), boolToFloat(failed), stats.Samples == 0)
+	if latency > 0 {
+		stats.HeaderLatency = ewma(stats.HeaderLatency, durationMillis(latency), stats.HeaderLatency == 0)
+	}
+	stats.Samples++
+	stats.Updated = time.Now()
+}
+
+// observeFirstByte records the time to first byte and marks the account for the next flush
+func (h *healthStats) observeFirstByte(accountID uint, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := h.get(accountID)
+	stats.FirstByte = ewma(stats.FirstByte, durationMillis(latency), stats.FirstByte == 0)
+	h.dirty[accountID] = true
+}
+
+// takeChanged returns the average time to first byte in ms of the accounts
+// observed since the last call
+func (h *healthStats) takeChanged() map[uint]int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	changed := make(map[uint]int64, len(h.dirty))
+	for accountID := range h.dirty {
+		changed[accountID] = int64(math.Round(h.accounts[accountID].FirstByte))
+	}
+	clear(h.dirty)
+	return changed
+}
+
+// snapshot returns a copy of the account's figures, with the error rate decayed to now
+func (h *healthStats) snapshot(accountID uint) accountStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats, ok := h.accounts[accountID]
+	if !ok {
+		return accountStats{}
+	}
+	copied := *stats
+	copied.ErrorRate = stats.decayedErrorRate(time.Now())
+	return copied
+}
+
+func (h *healthStats) get(accountID uint) *accountStats {
+	stats, ok := h.accounts[accountID]
+	if !ok {
+		stats = &accountStats{}
+		h.accounts[accountID] = stats
+	}
+	return stats
+}
+
+func (s *accountStats) decayedErrorRate(now time.Time) float64 {
+	if s.ErrorRate == 0 || s.Updated.IsZero() {
+		return s.ErrorRate
+	}
+	elapsed := now.Sub(s.Updated)
+	return s.ErrorRate * math.Pow(0.5, float64(elapsed)/float64(errorHalfLife))
+}
+
+// latency prefers the time to first byte, which includes the model's think time
+func (s *accountStats) latency() float64 {
+	if s.FirstByte > 0 {
+		return s.FirstByte
+	}
+	return s.HeaderLatency
+}
+
+// ewma folds sample into avg; the first sample is taken as-is
+func ewma(avg, sample float64, first bool) float64 {
+	if first {
+		return sample
+	}
+	return statsAlpha*sample + (1-statsAlpha)*avg
+}
+
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isFailedAttempt reports whether an upstream status counts against the account's error rate.
+// Other 4xx responses are the client's doing and say nothing about the account.
+func isFailedAttempt(statusCode int) bool {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= 500
+}
+
+// selectAdaptive picks an account at random in proportion to its score, which
+// favours low latency, a low recent error rate and plenty of remaining quota.
+// Accounts without measurements are scored as if they were the fastest, so
+// they get probed.
+func (r *Router) selectAdaptive(accounts []storage.Account) (*storage.Account, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts available")
+	}
+
+	stats := make([]accountStats, len(accounts))
+	fastest := 0.0
+	for i := range accounts {
+		stats[i] = r.stats.snapshot(accounts[i].ID)
+		if l := stats[i].latency(); l > 0 && (fastest == 0 || l < fastest) {
+			fastest = l
+		}
+	}
+
+	scores := make([]float64, len(accounts))
+	total := 0.0
+	for i := range accounts {
+		score := adaptiveScore(&accounts[i], stats[i], fastest)
+		scores[i] = score
+		total += score
+	}
+
+	pick := rand.Float64() * total
+	for i := range accounts {
+		pick -= scores[i]
+		if pick < 0 {
+			return &accounts[i], nil
+		}
+	}
+	return &accounts[len(accounts)-1], nil
+}
+
+// adaptiveScore rates an account between minAdaptiveScore and 1
+func adaptiveScore(account *storage.Account, stats accountStats, fastest float64) float64 {
+	latencyFactor := 1.0
+	if l := stats.latency(); l > 0 && fastest > 0 {
+		latencyFactor = fastest / l
+	}
+
+	success := 1 - stats.ErrorRate
+	successFactor := success * success
+
+	// Running low on quota only dampens the score; the account still serves
+	// until cooldown takes it out of the pool
+	quotaFactor := 0.25 + 0.75*accountHeadroom(account, time.Now())
+
+	return math.Max(latencyFactor*successFactor*quotaFactor, minAdaptiveScore)
+}
+
+// firstByteReader reports how long the upstream took to send the first body byte
+type firstByteReader struct {
+	body   io.ReadCloser
+	start  time.Time
+	once   sync.Once
+	onByte func(latency time.Duration)
+}
+
+func (f *firstByteReader) Read(p []byte) (int, error) {
+	n, err := f.body.Read(p)
+	if n > 0 {
+		f.once.Do(func() { f.onByte(time.Since(f.start)) })
+	}
+	return n, err
+}
+
+func (f *firstByteReader) Close() error {
+	return f.body.Close()
+}
+
+// observeAttempt feeds an attempt's outcome into the adaptive stats and, for
+// successful responses, times the first body byte. The averages reach
+// ProviderHealth.ResponseTime with the next flushHealth.
+func (s *Server) observeAttempt(req *http.Request, attempt *upstreamAttempt, resp *http.Response, err error) {
+	if req.Context().Err() != nil {
+		// The client gave up; that says nothing about the account
+		return
+	}
+
+	accountID := attempt.Account.ID
+	if err != nil {
+		s.router.stats.observe(accountID, 0, true)
+		return
+	}
+
+	failed := isFailedAttempt(resp.StatusCode)
+	s.router.stats.observe(accountID, time.Since(attempt.Started), failed)
+	if failed || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+
+	resp.Body = &firstByteReader{
+		body:  resp.Body,
+		start: attempt.Started,
+		onByte: func(latency time.Duration) {
+			s.router.stats.observeFirstByte(accountID, latency)
+		},
+	}
+}
+
+// flushHealthLoop persists changed response times every healthFlushInterval,
+// off the request path, until done is closed
+func (s *Server) flushHealthLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(healthFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushHealth()
+		case <-done:
+			return
+		}
+	}
+}
+
+// flushHealth persists the response times that changed since the last flush
+func (s *Server) flushHealth() {
+	for accountID, ms := range s.router.stats.takeChanged() {
+		s.saveResponseTime(accountID, ms)
+	}
+}
+
+// saveResponseTime stores the account's average response time in its health record
+func (s *Server) saveResponseTime(accountID uint, ms int64) {
+	err := s.updateHealth(accountID, map[string]interface{}{"response_time": ms, "last_checked": time.Now()})
+	if err != nil {
+		log.Printf("Failed to save response time for account %d: %v", accountID, err)
+	}
+}
+
+// updateHealth sets fields on the account's health record, creating the record if needed
+func (s *Server) updateHealth(accountID uint, fields map[string]interface{}) error {
+	var health storage.ProviderHealth
+	err := s.db.Where(storage.ProviderHealth{AccountID: accountID}).
+		Attrs(storage.ProviderHealth{IsHealthy: true}).
+		FirstOrCreate(&health).Error
+	if err != nil {
+		return err
+	}
+	return s.db.Model(&health).Updates(fields).Error
+}
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionHeader lets clients name their conversation explicitly for sticky routing
+const sessionHeader = "X-Quotio-Session"
+
+// defaultSessionTTL is used when ProxyConfig.SessionTTL isn't set
+const defaultSessionTTL = 30 * time.Minute
+
+// affinityTable pins conversations to the account that served them, so
+// consecutive turns reuse that account's prompt cache
+type affinityTable struct {
+	mu        sync.Mutex
+	entries   map[string]affinityEntry
+	lastSweep time.Time
+}
+
+type affinityEntry struct {
+	AccountID uint
+	Expires   time.Time
+}
+
+func newAffinityTable() *affinityTable {
+	return &affinityTable{entries: make(map[string]affinityEntry)}
+}
+
+// lookup returns the account pinned to the session, if the pin hasn't expired
+func (t *affinityTable) lookup(session string) (uint, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[session]
+	if !ok || time.Now().After(entry.Expires) {
+		return 0, false
+	}
+	return entry.AccountID, true
+}
+
+// pin (re)assigns the session to an account for another ttl
+func (t *affinityTable) pin(session string, accountID uint, ttl time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.entries[session] = affinityEntry{AccountID: accountID, Expires: now.Add(ttl)}
+
+	// Drop expired sessions now and then so the table doesn't grow unbounded
+	if now.Sub(t.lastSweep) > time.Minute {
+		for key, entry := range t.entries {
+			if now.After(entry.Expires) {
+				delete(t.entries, key)
+			}
+		}
+		t.lastSweep = now
+	}
+}
+
+// sessionKey identifies the conversation a request belongs to: the client's
+// X-Quotio-Session header if set, otherwise a hash of the system prompt and the
+// first user message, which stay the same on every turn of a conversation
+func sessionKey(header http.Header, body []byte) string {
+	if session := strings.TrimSpace(header.Get(sessionHeader)); session != "" {
+		return "header:" + session
+	}
+	return conversationKey(body)
+}
+
+// conversationKey hashes the opening of a conversation in any supported API
+// format. It returns "" when the body has no user message to key on.
+func conversationKey(body []byte) string {
+	var req struct {
+		System            json.RawMessage `json:"system"`            // Anthropic
+		Instructions      json.RawMessage `json:"instructions"`      // Responses
+		SystemInstruction json.RawMessage `json:"systemInstruction"` // Gemini
+		Messages          []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"` // OpenAI and Anthropic
+		Contents []struct {
+			Role  string          `json:"role"`
+			Parts json.RawMessage `json:"parts"`
+		} `json:"contents"` // Gemini
+		Input json.RawMessage `json:"input"` // Responses: string or items
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(body), &req); err != nil {
+		return ""
+	}
+
+	system := [][]byte{req.System, req.Instructions, req.SystemInstruction}
+	var firstUser []byte
+	for _, msg := range req.Messages {
+		if msg.Role == "system" || msg.Role == "developer" {
+			system = append(system, msg.Content)
+			continue
+		}
+		if msg.Role == "user" {
+			firstUser = msg.Content
+			break
+		}
+	}
+	if firstUser == nil {
+		for _, content := range req.Contents {
+			if content.Role == "" || content.Role == "user" {
+				firstUser = content.Parts
+				break
+			}
+		}
+	}
+	if firstUser == nil && len(req.Input) > 0 {
+		firstUser = firstResponsesInput(req.Input)
+	}
+	if firstUser == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, part := range system {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	h.Write(firstUser)
+	return "conversation:" + hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// firstResponsesInput returns the first user input of a Responses request
+func firstResponsesInput(input json.RawMessage) []byte {
+	var text string
+	if err := json.Unmarshal(input, &text); err == nil {
+		return []byte(text)
+	}
+
+	var items []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(input, &items); err != nil {
+		return nil
+	}
+	for _, item := range items {
+		if item.Role == "user" {
+			return item.Content
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"log"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"sync"
+)
+
+// aliasCache holds the model aliases in memory, so resolving one doesn't query
+// the database on every request. It is loaded on first use and again after
+// each invalidate.
+type aliasCache struct {
+	mu      sync.RWMutex
+	loaded  bool
+	targets map[string][]string // Alias name to the model IDs it resolves to
+}
+
+func newAliasCache() *aliasCache {
+	return &aliasCache{}
+}
+
+// lookup returns the model IDs the named alias resolves to, or nil if there is
+// no such alias. The returned slice is shared and must not be modified.
+func (c *aliasCache) lookup(name string) ([]string, error) {
+	c.mu.RLock()
+	if c.loaded {
+		defer c.mu.RUnlock()
+		return c.targets[name], nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded {
+		aliases, err := storage.GetModelAliases()
+		if err != nil {
+			return nil, err
+		}
+		c.targets = make(map[string][]string, len(aliases))
+		for _, alias := range aliases {
+			c.targets[alias.Name] = alias.Targets()
+		}
+		c.loaded = true
+	}
+	return c.targets[name], nil
+}
+
+// invalidate drops the loaded aliases so the next request reads them afresh
+func (c *aliasCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loaded = false
+	c.targets = nil
+}
+
+// InvalidateModelAliases makes the proxy reload the model aliases, after one is
+// created, updated or deleted
+func (s *Server) InvalidateModelAliases() {
+	s.aliases.invalidate()
+}
+
+// resolveModelAlias looks the requested model up among the user's aliases and,
+// if it is one, records the model IDs it stands for
+func (s *Server) resolveModelAlias(info *requestInfo) {
+	if info.Model == "" {
+		return
+	}
+
+	targets, err := s.aliases.lookup(info.Model)
+	if err != nil {
+		log.Printf("Failed to look up model alias %s: %v", info.Model, err)
+		return
+	}
+	if len(targets) > 0 {
+		info.Targets = targets
+	}
+}
+
+// aliasTarget returns the first of an alias's targets that the account offers,
+// or "" if it offers none
+func aliasTarget(account *storage.Account, targets []string) string {
+	for _, model := range targets {
+		if providers.AccountServesModel(account, model) {
+			return model
+		}
+	}
+	return ""
+}
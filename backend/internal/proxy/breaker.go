@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"quotio-electron-go/backend/internal/storage"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Circuit states, as persisted in ProviderHealth.CircuitState
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+const (
+	// circuitFailureThreshold is how many server errors or dial failures in a
+	// row open an account's circuit
+	circuitFailureThreshold = 5
+	// circuitOpenDuration is how long a freshly opened circuit keeps the account
+	// out of rotation before a probe request is let through
+	circuitOpenDuration = 30 * time.Second
+	// maxCircuitOpenDuration caps the backoff after repeated failed probes
+	maxCircuitOpenDuration = 10 * time.Minute
+)
+
+// circuit is the breaker state of one account
+type circuit struct {
+	State    string
+	Failures int           // Consecutive server errors or dial failures
+	OpenFor  time.Duration // Current open period, doubled by each failed probe
+	RetryAt  time.Time     // When an open circuit lets a probe through
+	Probing  bool          // A half-open probe request is in flight
+}
+
+// circuitBreakers keeps accounts that keep failing upstream out of rotation.
+// A circuit opens after circuitFailureThreshold failures in a row, lets a single
+// probe through once its open period is over, and closes again when the probe
+// succeeds.
+type circuitBreakers struct {
+	mu       sync.Mutex
+	circuits map[uint]*circuit
+}
+
+func newCircuitBreakers() *circuitBreakers {
+	return &circuitBreakers{circuits: make(map[uint]*circuit)}
+}
+
+// seed restores the circuits that were not closed when the proxy last ran
+func (b *circuitBreakers) seed(db *gorm.DB) {
+	var healths []storage.ProviderHealth
+	if err := db.Where("circuit_state IN ?", []string{CircuitOpen, CircuitHalfOpen}).Find(&healths).Error; err != nil {
+		log.Printf("Failed to load circuit breaker state: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, health := range healths {
+		// A probe interrupted by the restart is retried as soon as possible
+		b.circuits[health.AccountID] = &circuit{
+			State:    CircuitOpen,
+			Failures: health.CircuitFailures,
+			OpenFor:  circuitOpenDuration,
+			RetryAt:  health.CircuitRetryAt,
+		}
+	}
+}
+
+// available reports whether the account may be selected: its circuit is
+// closed, or open past its retry time with no probe already in flight
+func (b *circuitBreakers) available(accountID uint, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[accountID]
+	if !ok || c.State == CircuitClosed {
+		return true
+	}
+	return !c.Probing && !now.Before(c.RetryAt)
+}
+
+// acquire lets a request through to the account. If the circuit isn't closed
+// the request becomes its probe, and the circuit turns half-open until the
+// outcome is recorded; the new state is returned so it can be persisted.
+func (b *circuitBreakers) acquire(accountID uint, now time.Time) (bool, *circuit) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[accountID]
+	if !ok || c.State == CircuitClosed {
+		return true, nil
+	}
+	if c.Probing || now.Before(c.RetryAt) {
+		return false, nil
+	}
+	c.State = CircuitHalfOpen
+	c.Probing = true
+	copied := *c
+	return true, &copied
+}
+
+// record feeds an attempt's outcome into the account's circuit. It returns the
+// new state when the circuit changed state, or nil.
+func (b *circuitBreakers) record(accountID uint, failed bool, now time.Time) *circuit {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[accountID]
+	if !ok {
+		if !failed {
+			return nil
+		}
+		c = &circuit{State: CircuitClosed}
+		b.circuits[accountID] = c
+	}
+
+	if !failed {
+		if c.State == CircuitClosed && c.Failures == 0 {
+			return nil
+		}
+		changed := c.State != CircuitClosed
+		*c = circuit{State: CircuitClosed}
+		if !changed {
+			return nil
+		}
+		copied := *c
+		return &copied
+	}
+
+	c.Failures++
+	switch c.State {
+	case CircuitHalfOpen:
+		// The probe failed: back off for longer before the next one
+		c.OpenFor = min(c.OpenFor*2, maxCircuitOpenDuration)
+	case CircuitClosed:
+		if c.Failures < circuitFailureThreshold {
+			return nil
+		}
+		c.OpenFor = circuitOpenDuration
+	default:
+		// A request that was already in flight when the circuit opened
+		return nil
+	}
+	c.State = CircuitOpen
+	c.Probing = false
+	c.RetryAt = now.Add(c.OpenFor)
+	copied := *c
+	return &copied
+}
+
+// abandon gives up a probe whose outcome says nothing about the account, such
+// as one the client cancelled, so the next request can probe instead
+func (b *circuitBreakers) abandon(accountID uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[accountID]; ok && c.State == CircuitHalfOpen {
+		c.State = CircuitOpen
+		c.Probing = false
+	}
+}
+
+// isServerFailure reports whether an attempt counts against the account's
+// circuit: it never got a response, or the provider answered with a 5xx
+// (including Anthropic's 529 overloaded)
+func isServerFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500
+}
+
+// recordCircuit updates the account's circuit after an attempt and persists
+// any state change to its ProviderHealth record
+func (s *Server) recordCircuit(req *http.Request, account *storage.Account, resp *http.Response, err error) {
+	if req.Context().Err() != nil || errors.Is(err, context.Canceled) {
+		// The client gave up; that says nothing about the account
+		s.router.breakers.abandon(account.ID)
+		return
+	}
+
+	changed := s.router.breakers.record(account.ID, isServerFailure(resp, err), time.Now())
+	if changed == nil {
+		return
+	}
+	if changed.State == CircuitOpen {
+		log.Printf("Circuit opened for account %d after %d consecutive failures - retrying at %s",
+			account.ID, changed.Failures, changed.RetryAt.Format(time.RFC3339))
+	} else {
+		log.Printf("Circuit closed for account %d", account.ID)
+	}
+	s.saveCircuit(account.ID, changed)
+}
+
+// saveCircuit stores the account's breaker state in its health record
+func (s *Server) saveCircuit(accountID uint, c *circuit) {
+	err := s.updateHealth(accountID, map[string]interface{}{
+		"circuit_state":    c.State,
+		"circuit_failures": c.Failures,
+		"circuit_retry_at": c.RetryAt,
+		"is_healthy":       c.State == CircuitClosed,
+	})
+	if err != nil {
+		log.Printf("Failed to save circuit state for account %d: %v", accountID, err)
+	}
+}
@@ -0,0 +1,235 @@
+package proxy
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"quotio-electron-go/backend/internal/storage"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// cacheHeader reports hit or miss to clients, and lets them send "bypass"
+	cacheHeader = "X-Quotio-Cache"
+	// defaultCacheTTL and defaultCacheBytes are used when ProxyConfig leaves them unset
+	defaultCacheTTL   = 5 * time.Minute
+	defaultCacheBytes = 64 * 1024 * 1024
+)
+
+// responseCache holds complete responses to deterministic requests, evicting
+// the least recently used entries once the total body size exceeds its limit
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Front is most recently used
+	size    int
+}
+
+type cachedResponse struct {
+	Key        string
+	AccountID  uint // Account that produced the response, for attributing hits
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Expires    time.Time
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns the live entry for key, if any
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cachedResponse)
+	if time.Now().After(entry.Expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry, true
+}
+
+// put stores entry, then evicts old entries until the cache fits in maxBytes.
+// Entries larger than the whole cache are not stored.
+func (c *responseCache) put(entry *cachedResponse, maxBytes int) {
+	if len(entry.Body) > maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[entry.Key]; ok {
+		c.remove(elem)
+	}
+	c.entries[entry.Key] = c.lru.PushFront(entry)
+	c.size += len(entry.Body)
+
+	for c.size > maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *responseCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cachedResponse)
+	delete(c.entries, entry.Key)
+	c.size -= len(entry.Body)
+}
+
+// cacheBypassed reports whether the client asked not to be served from the cache
+func cacheBypassed(header http.Header) bool {
+	if strings.EqualFold(header.Get(cacheHeader), "bypass") {
+		return true
+	}
+	cacheControl := strings.ToLower(header.Get("Cache-Control"))
+	return strings.Contains(cacheControl, "no-cache") || strings.Contains(cacheControl, "no-store")
+}
+
+// responseCacheKey returns the cache key for a deterministic, non-streaming
+// generation request, or "" if the request shouldn't be cached. Only requests
+// that set temperature to 0 count as deterministic. The body is normalized
+// (keys sorted, whitespace dropped) so equivalent requests share an entry, as
+// long as they come from clients with the same provider scope.
+func responseCacheKey(info *requestInfo) string {
+	if info.Stream || !info.Translatable || len(info.Body) == 0 {
+		return ""
+	}
+
+	var payload struct {
+		Temperature      *float64 `json:"temperature"`
+		GenerationConfig *struct {
+			Temperature *float64 `json:"temperature"`
+		} `json:"generationConfig"` // Gemini
+	}
+	if err := json.Unmarshal(info.Body, &payload); err != nil {
+		return ""
+	}
+	temperature := payload.Temperature
+	if payload.GenerationConfig != nil && payload.GenerationConfig.Temperature != nil {
+		temperature = payload.GenerationConfig.Temperature
+	}
+	if temperature == nil || *temperature != 0 {
+		return ""
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(info.Body, &body); err != nil {
+		return ""
+	}
+	normalized, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+
+	// Keys scoped to different providers may not share answers, since either
+	// could be served one from an account the other isn't allowed to use. The
+	// targets differ too when a larger-context reroute was limited to the key's models.
+	var scope []string
+	if info.ClientKey != nil {
+		scope = info.ClientKey.ProviderScope()
+		sort.Strings(scope)
+	}
+
+	h := sha256.New()
+	for _, part := range []string{info.Provider, strings.Join(scope, ","), info.Path, info.Model, strings.Join(info.Targets, ",")} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(normalized)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheableHeaders returns the response headers worth replaying on a cache hit
+func cacheableHeaders(header http.Header) http.Header {
+	cached := header.Clone()
+	for _, name := range []string{"Content-Length", "Date", "Connection", "Transfer-Encoding", "Set-Cookie"} {
+		cached.Del(name)
+	}
+	return cached
+}
+
+// cacheLookup ties a request that missed the cache to the entry its response should fill
+type cacheLookup struct {
+	Key      string
+	TTL      time.Duration
+	MaxBytes int
+}
+
+// newCacheLookup applies the configured TTL and size limit, or their defaults
+func newCacheLookup(key string, config storage.ProxyConfig) *cacheLookup {
+	lookup := &cacheLookup{
+		Key:      key,
+		TTL:      time.Duration(config.ResponseCacheTTL) * time.Second,
+		MaxBytes: config.ResponseCacheMB * 1024 * 1024,
+	}
+	if lookup.TTL <= 0 {
+		lookup.TTL = defaultCacheTTL
+	}
+	if lookup.MaxBytes <= 0 {
+		lookup.MaxBytes = defaultCacheBytes
+	}
+	return lookup
+}
+
+// cacheResponse stores a successful response in the cache once the client has read all of it
+func (s *Server) cacheResponse(resp *http.Response, attempt *upstreamAttempt, lookup *cacheLookup) {
+	resp.Header.Set(cacheHeader, "miss")
+	if resp.StatusCode != http.StatusOK || isEventStream(resp) || resp.Body == nil {
+		return
+	}
+
+	header := cacheableHeaders(resp.Header)
+	resp.Body = &bodyRecorder{
+		body:  resp.Body,
+		limit: lookup.MaxBytes,
+		onDone: func(data []byte, truncated, complete bool) {
+			if !complete || truncated {
+				return
+			}
+			s.cache.put(&cachedResponse{
+				Key:        lookup.Key,
+				AccountID:  attempt.Account.ID,
+				StatusCode: resp.StatusCode,
+				Header:     header,
+				Body:       append([]byte(nil), data...),
+				Expires:    time.Now().Add(lookup.TTL),
+			}, lookup.MaxBytes)
+		},
+	}
+}
+
+// serveCached writes a cached response and records it as a zero-token request
+// against the account that originally served it
+func (s *Server) serveCached(w http.ResponseWriter, info *requestInfo, entry *cachedResponse) {
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(cacheHeader, "hit")
+	w.WriteHeader(entry.StatusCode)
+	w.Write(entry.Body)
+
+	s.quotaTracker.Record(storage.QuotaHistory{
+		AccountID:     entry.AccountID,
+		RequestsCount: 1,
+		Model:         info.Model,
+		StatusCode:    entry.StatusCode,
+		Success:       true,
+		ClientKeyID:   info.clientKeyID(),
+		Cached:        true,
+	})
+}
@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"strings"
+	"sync"
+	"time"
+)
+
+// captureBodyLimit caps how much of each request and response body is stored
+const captureBodyLimit = 256 * 1024
+
+// redactedValue replaces credentials in captured headers and URLs
+const redactedValue = "REDACTED"
+
+// sensitiveHeaders carry credentials and are never stored in captures
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "X-Api-Key", "X-Goog-Api-Key", "Cookie", "Set-Cookie"}
+
+// sensitiveQueryParams carry credentials in URLs, such as the Gemini API key
+var sensitiveQueryParams = []string{"key", "api_key", "access_token"}
+
+// ErrReplayIncompatible is returned when a capture can't be replayed through the chosen account
+var ErrReplayIncompatible = errors.New("capture can't be replayed through this account")
+
+// redactHeaders returns a copy of h with credential headers masked
+func redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, redactedValue)
+		}
+	}
+	return redacted
+}
+
+// redactURL renders u with credential query parameters masked
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for _, name := range sensitiveQueryParams {
+		if query.Has(name) {
+			query.Set(name, redactedValue)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// captureExchange records the attempt's upstream request and, once its body has
+// been read, the response. The returned response carries the recording body.
+func (s *Server) captureExchange(outreq *http.Request, path string, body []byte, attempt *upstreamAttempt, resp *http.Response, err error) *http.Response {
+	keep := attempt.CaptureLimit
+	capture := &storage.Capture{
+		AccountID:      attempt.Account.ID,
+		Provider:       attempt.Account.Provider,
+		ClientKeyID:    attempt.ClientKeyID,
+		Model:          attempt.Model,
+		Format:         attempt.UpstreamFormat,
+		Attempt:        attempt.Number,
+		Method:         outreq.Method,
+		Path:           path,
+		UpstreamURL:    redactURL(outreq.URL),
+		RequestHeaders: redactHeaders(outreq.Header),
+		CreatedAt:      attempt.Started, // Saved asynchronously, so stamp when the attempt began
+	}
+	capture.RequestBody, capture.RequestTruncated = truncateBody(body)
+
+	save := func() {
+		capture.DurationMs = time.Since(attempt.Started).Milliseconds()
+		go func() {
+			if err := storage.SaveCapture(capture, keep); err != nil {
+				log.Printf("Failed to save capture: %v", err)
+			}
+		}()
+	}
+
+	if err != nil {
+		capture.Error = err.Error()
+		save()
+		return resp
+	}
+
+	capture.StatusCode = resp.StatusCode
+	capture.ResponseHeaders = redactHeaders(resp.Header)
+	resp.Body = &bodyRecorder{
+		body:  resp.Body,
+		limit: captureBodyLimit,
+		onDone: func(data []byte, truncated, complete bool) {
+			capture.ResponseBody = string(data)
+			capture.ResponseTruncated = truncated
+			save()
+		},
+	}
+	return resp
+}
+
+// truncateBody returns the body as stored in a capture and whether it was cut short
+func truncateBody(body []byte) (string, bool) {
+	if len(body) > captureBodyLimit {
+		return string(body[:captureBodyLimit]), true
+	}
+	return string(body), false
+}
+
+// bodyRecorder keeps a copy of up to limit bytes of a response body as the
+// client reads it. onDone runs once, when the body ends or is closed; complete
+// reports whether it was read through to EOF.
+type bodyRecorder struct {
+	body      io.ReadCloser
+	limit     int
+	buf       bytes.Buffer
+	truncated bool
+	once      sync.Once
+	onDone    func(data []byte, truncated, complete bool)
+}
+
+func (b *bodyRecorder) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 {
+		room := b.limit - b.buf.Len()
+		if n > room {
+			b.truncated = true
+		}
+		if room > 0 {
+			b.buf.Write(p[:min(n, room)])
+		}
+	}
+	if err != nil {
+		b.finish(err == io.EOF)
+	}
+	return n, err
+}
+
+func (b *bodyRecorder) Close() error {
+	err := b.body.Close()
+	b.finish(false)
+	return err
+}
+
+func (b *bodyRecorder) finish(complete bool) {
+	b.once.Do(func() {
+		b.onDone(b.buf.Bytes(), b.truncated, complete)
+	})
+}
+
+// ReplayCapture resends a captured upstream request through the given account,
+// authenticating with that account's credentials. The caller must close the
+// response body.
+func ReplayCapture(capture *storage.Capture, account *storage.Account) (*http.Response, error) {
+	if capture.RequestTruncated {
+		return nil, fmt.Errorf("%w: the captured request body was truncated", ErrReplayIncompatible)
+	}
+
+	provider := providers.GetProviderForAccount(account)
+	if provider == nil {
+		return nil, fmt.Errorf("provider not found: %s", account.Provider)
+	}
+	if capture.Format != "" && !providers.SupportsAPIFormat(provider, capture.Format) {
+		return nil, fmt.Errorf("%w: %s doesn't speak the %s API", ErrReplayIncompatible, account.Provider, capture.Format)
+	}
+
+	target, err := url.Parse(provider.GetBaseURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider URL: %w", err)
+	}
+	captured, err := url.Parse(capture.UpstreamURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid captured URL: %w", err)
+	}
+
+	// Keep the captured query minus the original account's credentials
+	query := captured.Query()
+	for _, name := range sensitiveQueryParams {
+		query.Del(name)
+	}
+	target.Path = provider.GetUpstreamPath(capture.Path)
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(capture.Method, target.String(), strings.NewReader(capture.RequestBody))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range capture.RequestHeaders {
+		switch http.CanonicalHeaderKey(name) {
+		case "Host", "Content-Length", "Accept-Encoding":
+			continue
+		}
+		req.Header[http.CanonicalHeaderKey(name)] = values
+	}
+	for _, name := range sensitiveHeaders {
+		req.Header.Del(name)
+	}
+
+	if err := provider.AuthenticateRequest(req, account); err != nil {
+		return nil, fmt.Errorf("failed to authenticate upstream request: %w", err)
+	}
+	return http.DefaultClient.Do(req)
+}
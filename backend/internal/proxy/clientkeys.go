@@ -0,0 +1,333 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"quotio-electron-go/backend/internal/storage"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientCredentialHeaders carry the client's proxy key. They are removed before
+// upstream authentication so a client key never reaches a provider.
+var clientCredentialHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key"}
+
+// clientCredential returns the key the client presented, in whichever way its
+// API sends it: OpenAI-style bearer token, Anthropic x-api-key, or Gemini's
+// x-goog-api-key header or key query parameter
+func clientCredential(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	for _, header := range clientCredentialHeaders[1:] {
+		if key := strings.TrimSpace(r.Header.Get(header)); key != "" {
+			return key
+		}
+	}
+	return r.URL.Query().Get("key")
+}
+
+// stripClientCredentials removes the client's proxy key from an outgoing request
+func stripClientCredentials(req *http.Request) {
+	for _, header := range clientCredentialHeaders {
+		req.Header.Del(header)
+	}
+	if query := req.URL.Query(); query.Has("key") {
+		query.Del("key")
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// clientAuthError is why a client request was turned away
+type clientAuthError struct {
+	Status  int
+	Message string
+}
+
+// clientKeyTouchInterval is how stale a key's last_used_at may get, so busy
+// keys cost at most one write per interval
+const clientKeyTouchInterval = time.Minute
+
+// clientKeyCache holds the issued client keys in memory, so authenticating a
+// request doesn't query the database. It is loaded on first use and again
+// after each invalidate.
+type clientKeyCache struct {
+	mu      sync.RWMutex
+	loaded  bool
+	byHash  map[string]storage.ClientKey // Every issued key, revoked ones included
+	touched map[uint]time.Time           // When last_used_at was last written per key
+}
+
+func newClientKeyCache() *clientKeyCache {
+	return &clientKeyCache{touched: make(map[uint]time.Time)}
+}
+
+// lookup returns the key with the given hash and how many keys have been issued
+func (c *clientKeyCache) lookup(hash string) (*storage.ClientKey, int, error) {
+	c.mu.RLock()
+	if c.loaded {
+		defer c.mu.RUnlock()
+		return c.find(hash)
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded {
+		keys, err := storage.GetClientKeys()
+		if err != nil {
+			return nil, 0, err
+		}
+		c.byHash = make(map[string]storage.ClientKey, len(keys))
+		for _, key := range keys {
+			c.byHash[key.KeyHash] = key
+		}
+		c.loaded = true
+	}
+	return c.find(hash)
+}
+
+// find looks a hash up in the loaded keys. The caller must hold c.mu.
+func (c *clientKeyCache) find(hash string) (*storage.ClientKey, int, error) {
+	key, ok := c.byHash[hash]
+	if !ok {
+		return nil, len(c.byHash), nil
+	}
+	return &key, len(c.byHash), nil
+}
+
+// invalidate drops the loaded keys so the next request reads them afresh
+func (c *clientKeyCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loaded = false
+	c.byHash = nil
+}
+
+// touch records that a key was used, writing last_used_at in the background at
+// most once per clientKeyTouchInterval
+func (c *clientKeyCache) touch(keyID uint, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.touched[keyID]) < clientKeyTouchInterval {
+		return
+	}
+	c.touched[keyID] = now
+
+	go func() {
+		if err := storage.TouchClientKey(keyID); err != nil {
+			log.Printf("Failed to record use of client key %d: %v", keyID, err)
+		}
+	}()
+}
+
+// InvalidateClientKeys makes the proxy reload the client keys, after one is
+// created, revoked or rotated
+func (s *Server) InvalidateClientKeys() {
+	s.clientKeys.invalidate()
+}
+
+// authenticateClient checks the presented credential against the shared
+// ProxyConfig.APIKey and the issued client keys. It returns the matching client
+// key, or nil when the shared key was used or no authentication is configured.
+// Once any client key has been issued the proxy stays closed, even if every
+// key is later revoked.
+func (s *Server) authenticateClient(r *http.Request, config storage.ProxyConfig) (*storage.ClientKey, *clientAuthError) {
+	credential := clientCredential(r)
+	key, issuedKeys, err := s.clientKeys.lookup(storage.HashClientKey(credential))
+	if err != nil {
+		log.Printf("Failed to load client keys: %v", err)
+	}
+	if config.APIKey == "" && issuedKeys == 0 && err == nil {
+		return nil, nil
+	}
+
+	unauthorized := &clientAuthError{Status: http.StatusUnauthorized, Message: "invalid or missing API key"}
+	if credential == "" {
+		return nil, unauthorized
+	}
+	if config.APIKey != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(config.APIKey)) == 1 {
+		return nil, nil
+	}
+
+	if key == nil || key.Revoked {
+		return nil, unauthorized
+	}
+	return key, nil
+}
+
+// authorizeClient enforces a client key's model scope, token budgets and
+// request rate. Provider scope is enforced by the router. An alias in the
+// request must already be resolved: its targets are narrowed to the key's
+// model scope, and the request is refused if none are left.
+func (s *Server) authorizeClient(key *storage.ClientKey, info *requestInfo) *clientAuthError {
+	if models := key.ModelScope(); len(models) > 0 && info.Model != "" {
+		forbidden := &clientAuthError{Status: http.StatusForbidden, Message: fmt.Sprintf("model %s is not allowed for this API key", info.Model)}
+		if len(info.Targets) == 0 && !containsString(models, info.Model) {
+			return forbidden
+		}
+		if len(info.Targets) > 0 {
+			var allowed []string
+			for _, target := range info.Targets {
+				if containsString(models, target) {
+					allowed = append(allowed, target)
+				}
+			}
+			if len(allowed) == 0 {
+				return forbidden
+			}
+			info.Targets = allowed
+		}
+	}
+	if providers := key.ProviderScope(); len(providers) > 0 && info.Provider != "" && !containsString(providers, info.Provider) {
+		return &clientAuthError{Status: http.StatusForbidden, Message: fmt.Sprintf("provider %s is not allowed for this API key", info.Provider)}
+	}
+
+	now := time.Now()
+	if key.DailyTokenBudget > 0 || key.MonthlyTokenBudget > 0 {
+		daily, monthly, err := s.keyUsage.totals(key.ID, now)
+		if err != nil {
+			log.Printf("Failed to read token usage for client key %d: %v", key.ID, err)
+		}
+		budgets := []struct {
+			period string
+			limit  int64
+			used   int64
+		}{
+			{"daily", key.DailyTokenBudget, daily},
+			{"monthly", key.MonthlyTokenBudget, monthly},
+		}
+		for _, budget := range budgets {
+			if budget.limit > 0 && budget.used >= budget.limit {
+				return &clientAuthError{Status: http.StatusTooManyRequests, Message: fmt.Sprintf("%s token budget exhausted for this API key", budget.period)}
+			}
+		}
+	}
+
+	if !s.keyLimiter.allow(key.ID, key.RPM, now) {
+		return &clientAuthError{Status: http.StatusTooManyRequests, Message: "request rate limit exceeded for this API key"}
+	}
+	return nil
+}
+
+// keyTokenUsage keeps running daily and monthly token totals per client key, so
+// budget checks don't sum QuotaHistory on every request. A key's totals are read
+// from QuotaHistory the first time it is checked in a period, then kept current
+// as the proxy records usage.
+type keyTokenUsage struct {
+	mu   sync.Mutex
+	keys map[uint]*keyTotals
+}
+
+type keyTotals struct {
+	day, month     time.Time // Start of the periods the totals cover
+	daily, monthly int64
+}
+
+func newKeyTokenUsage() *keyTokenUsage {
+	return &keyTokenUsage{keys: make(map[uint]*keyTotals)}
+}
+
+// totals returns the tokens the key used today and this month
+func (u *keyTokenUsage) totals(keyID uint, now time.Time) (int64, int64, error) {
+	day, month := usagePeriods(now)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	t, ok := u.keys[keyID]
+	if !ok {
+		t = &keyTotals{}
+		u.keys[keyID] = t
+	}
+	if !t.day.Equal(day) {
+		used, err := storage.GetClientKeyTokensSince(keyID, day)
+		if err != nil {
+			return t.daily, t.monthly, err
+		}
+		t.day, t.daily = day, used
+	}
+	if !t.month.Equal(month) {
+		used, err := storage.GetClientKeyTokensSince(keyID, month)
+		if err != nil {
+			return t.daily, t.monthly, err
+		}
+		t.month, t.monthly = month, used
+	}
+	return t.daily, t.monthly, nil
+}
+
+// add counts tokens used by a key. Keys not checked yet in the current period
+// are skipped; their totals are read in full when they are.
+func (u *keyTokenUsage) add(keyID uint, tokens int64, now time.Time) {
+	if keyID == 0 || tokens <= 0 {
+		return
+	}
+	day, month := usagePeriods(now)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	t, ok := u.keys[keyID]
+	if !ok {
+		return
+	}
+	if t.day.Equal(day) {
+		t.daily += tokens
+	}
+	if t.month.Equal(month) {
+		t.monthly += tokens
+	}
+}
+
+// usagePeriods returns the start of the day and month that now falls in
+func usagePeriods(now time.Time) (time.Time, time.Time) {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+		time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+}
+
+// keyRateLimiter enforces per-client-key requests per minute over a sliding window
+type keyRateLimiter struct {
+	mu       sync.Mutex
+	requests map[uint][]time.Time
+}
+
+func newKeyRateLimiter() *keyRateLimiter {
+	return &keyRateLimiter{requests: make(map[uint][]time.Time)}
+}
+
+// allow records a request for the key if it is within rpm, which 0 leaves unlimited
+func (l *keyRateLimiter) allow(keyID uint, rpm int, now time.Time) bool {
+	if rpm <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window := now.Add(-time.Minute)
+	recent := l.requests[keyID][:0]
+	for _, at := range l.requests[keyID] {
+		if at.After(window) {
+			recent = append(recent, at)
+		}
+	}
+	if len(recent) >= rpm {
+		l.requests[keyID] = recent
+		return false
+	}
+	l.requests[keyID] = append(recent, now)
+	return true
+}
+
+func containsString(items []string, value string) bool {
+	for _, item := range items {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package proxy
+
+import (
+	"io"
+	"sync"
+)
+
+// inFlightCounter tracks how many upstream requests each account is serving right now
+type inFlightCounter struct {
+	mu     sync.Mutex
+	counts map[uint]int
+}
+
+func newInFlightCounter() *inFlightCounter {
+	return &inFlightCounter{counts: make(map[uint]int)}
+}
+
+// acquire claims a slot on the account unless it already runs limit requests.
+// A limit of 0 means unlimited.
+func (c *inFlightCounter) acquire(accountID uint, limit int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if limit > 0 && c.counts[accountID] >= limit {
+		return false
+	}
+	c.counts[accountID]++
+	return true
+}
+
+// release frees a slot claimed with acquire
+func (c *inFlightCounter) release(accountID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts[accountID] <= 1 {
+		delete(c.counts, accountID)
+		return
+	}
+	c.counts[accountID]--
+}
+
+// saturated reports whether the account has no free slot under limit
+func (c *inFlightCounter) saturated(accountID uint, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[accountID] >= limit
+}
+
+// snapshot returns the current in-flight count of every busy account
+func (c *inFlightCounter) snapshot() map[uint]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[uint]int, len(c.counts))
+	for id, n := range c.counts {
+		counts[id] = n
+	}
+	return counts
+}
+
+// releasingBody frees the account's slot once the response body is closed,
+// which for streams is when the last event has been relayed
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// InFlight returns how many requests each account is currently serving
+func (s *Server) InFlight() map[uint]int {
+	return s.router.inFlight.snapshot()
+}
@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"quotio-electron-go/backend/internal/translator"
+	"sort"
+	"strings"
+)
+
+// bytesPerToken is the rough size of one token of English text or code
+const bytesPerToken = 4
+
+// estimateTokens roughly counts the prompt tokens in a JSON request body from
+// the length of its text, ignoring JSON syntax, object keys and inline binary
+// data such as base64 images. It errs low, so only requests that clearly
+// exceed a context window are caught.
+func estimateTokens(body []byte) int {
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return 0
+	}
+	return textBytes(payload) / bytesPerToken
+}
+
+// textBytes sums the length of the string values in a decoded JSON value
+func textBytes(value interface{}) int {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "data:") {
+			return 0 // OpenAI inline image URL
+		}
+		return len(v)
+	case []interface{}:
+		total := 0
+		for _, item := range v {
+			total += textBytes(item)
+		}
+		return total
+	case map[string]interface{}:
+		total := 0
+		for key, item := range v {
+			if key == "data" {
+				continue // Anthropic image source and Gemini inlineData carry base64 here
+			}
+			total += textBytes(item)
+		}
+		return total
+	}
+	return 0
+}
+
+// catalogModel returns the catalog entry for a model. A model the catalog
+// doesn't list by ID, such as a dated snapshot like claude-3-5-sonnet-20241022,
+// falls back to the longest catalog ID it extends with a "-" suffix, preferring
+// the given provider's entry.
+func catalogModel(model, provider string) (providers.ModelInfo, bool) {
+	if info, ok := providers.GetModel(model, provider); ok {
+		return info, true
+	}
+
+	var best providers.ModelInfo
+	found := false
+	for _, m := range providers.SupportedModels {
+		if !strings.HasPrefix(model, m.ID+"-") {
+			continue
+		}
+		longer := len(m.ID) > len(best.ID)
+		preferred := len(m.ID) == len(best.ID) && provider != "" && m.Provider == provider && best.Provider != provider
+		if !found || longer || preferred {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// contextWindow returns the catalog context window of a model, 0 if it isn't known
+func contextWindow(model, provider string) int {
+	if info, ok := catalogModel(model, provider); ok {
+		return info.ContextWindow
+	}
+	return 0
+}
+
+// fitsContext reports whether a prompt of the estimated size fits the model.
+// Models that match no catalog entry, even by prefix, have no known window and
+// are assumed to fit, so their requests go upstream unchecked.
+func fitsContext(model, provider string, tokens int) bool {
+	window := contextWindow(model, provider)
+	return window == 0 || tokens <= window
+}
+
+// largerContextModels returns the catalog models whose window fits the prompt,
+// preferring the requested model's provider and then the smallest window that
+// fits. Models outside the client key's scope are left out.
+func largerContextModels(info *requestInfo, tokens int) []string {
+	requested, _ := catalogModel(info.Model, info.Provider)
+
+	var scope []string
+	if info.ClientKey != nil {
+		scope = info.ClientKey.ModelScope()
+	}
+
+	var fitting []providers.ModelInfo
+	seen := make(map[string]bool)
+	for _, model := range providers.SupportedModels {
+		if model.ContextWindow < tokens || seen[model.ID] {
+			continue
+		}
+		if info.Provider != "" && model.Provider != info.Provider {
+			continue
+		}
+		if len(scope) > 0 && !containsString(scope, model.ID) {
+			continue
+		}
+		seen[model.ID] = true
+		fitting = append(fitting, model)
+	}
+
+	sort.SliceStable(fitting, func(i, j int) bool {
+		iSame, jSame := fitting[i].Provider == requested.Provider, fitting[j].Provider == requested.Provider
+		if iSame != jSame {
+			return iSame
+		}
+		return fitting[i].ContextWindow < fitting[j].ContextWindow
+	})
+
+	models := make([]string, 0, len(fitting))
+	for _, model := range fitting {
+		models = append(models, model.ID)
+	}
+	return models
+}
+
+// checkContextWindow estimates the prompt size and compares it with the context
+// window of the models that may serve the request. Alias targets that are too
+// small are dropped. If no model fits, the request is rerouted to a
+// larger-context model when ContextReroute is on, or rejected with the
+// provider's context_length_exceeded error. It returns false if it rejected the
+// request.
+func (s *Server) checkContextWindow(w http.ResponseWriter, info *requestInfo, config storage.ProxyConfig) bool {
+	if !info.Translatable || info.Model == "" || len(info.Body) == 0 {
+		return true
+	}
+
+	candidates := info.Targets
+	if len(candidates) == 0 {
+		candidates = []string{info.Model}
+	}
+
+	// Estimating is only worth it when some candidate's window is known
+	window := 0
+	for _, model := range candidates {
+		window = max(window, contextWindow(model, info.Provider))
+	}
+	if window == 0 {
+		return true
+	}
+	info.PromptTokens = estimateTokens(info.Body)
+
+	var fitting []string
+	for _, model := range candidates {
+		if fitsContext(model, info.Provider, info.PromptTokens) {
+			fitting = append(fitting, model)
+		}
+	}
+	if len(fitting) == len(candidates) {
+		return true
+	}
+	if len(fitting) > 0 {
+		info.Targets = fitting
+		return true
+	}
+
+	if config.ContextReroute {
+		if larger := largerContextModels(info, info.PromptTokens); len(larger) > 0 {
+			log.Printf("Request of about %d tokens exceeds the %d-token window of %s, rerouting to a larger-context model",
+				info.PromptTokens, window, info.Model)
+			info.Targets = larger
+			info.Rerouted = true
+			return true
+		}
+	}
+
+	log.Printf("Rejecting request of about %d tokens for %s, whose context window is %d tokens", info.PromptTokens, info.Model, window)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(translator.ContextLengthError(info.Format, info.PromptTokens, window))
+	return false
+}
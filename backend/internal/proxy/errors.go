@@ -0,0 +1,86 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"quotio-electron-go/backend/internal/translator"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cooldownError is returned when the accounts that could serve a request are
+// all cooling down after upstream rate limits. Until is when the first of them
+// comes back.
+type cooldownError struct {
+	Until time.Time
+}
+
+func (e *cooldownError) Error() string {
+	return fmt.Sprintf("%v: all eligible accounts are cooling down until %s", ErrNoAccounts, e.Until.Format(time.RFC3339))
+}
+
+func (e *cooldownError) Unwrap() error {
+	return ErrNoAccounts
+}
+
+// coolingDown returns a cooldownError if accounts that match the request's
+// provider, format and model are cooling down, or nil if none are. Accounts
+// already tried for the request count too, since the cooldown may be theirs.
+func (r *Router) coolingDown(req RouteRequest, now time.Time) error {
+	var accounts []storage.Account
+	if err := r.db.Where("status = ? AND cooldown_until > ?", "cooldown", now).Find(&accounts).Error; err != nil {
+		return nil
+	}
+
+	var until time.Time
+	for i := range accounts {
+		account := &accounts[i]
+		if !accountMatchesRoute(account, req) {
+			continue
+		}
+		if req.Model != "" && aliasTarget(account, req.Targets) == "" && !providers.AccountServesModel(account, req.Model) {
+			continue
+		}
+		if until.IsZero() || account.CooldownUntil.Before(until) {
+			until = account.CooldownUntil
+		}
+	}
+	if until.IsZero() {
+		return nil
+	}
+	return &cooldownError{Until: until}
+}
+
+// retryAfterSeconds renders a wait as a Retry-After value, rounded up to whole seconds
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1))
+}
+
+// errorFormat picks the error shape the client understands: that of the API it
+// called, else Anthropic's or Gemini's if the request is addressed to that
+// provider, else OpenAI's
+func errorFormat(r *http.Request) string {
+	provider, path := splitProviderPrefix(r.URL.Path)
+	if format := inferAPIFormat(path); format != "" {
+		return format
+	}
+	switch {
+	case provider == "claude" || r.Header.Get("anthropic-version") != "":
+		return translator.FormatAnthropic
+	case provider == "gemini" || strings.HasPrefix(path, "/v1beta/"):
+		return translator.FormatGemini
+	}
+	return translator.FormatOpenAI
+}
+
+// writeProxyError writes an error for a request the proxy can't forward, in the
+// native error shape of the API the client is speaking
+func writeProxyError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(translator.StatusError(errorFormat(r), status, message))
+}
@@ -0,0 +1,372 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"quotio-electron-go/backend/internal/translator"
+	"time"
+)
+
+// defaultMaxAttempts is used when ProxyConfig.MaxAttempts isn't set
+const defaultMaxAttempts = 3
+
+// upstreamAttempt identifies the account serving one try of a proxied request
+type upstreamAttempt struct {
+	Account        *storage.Account
+	Model          string   // Model sent upstream
+	ClientModel    string   // Model the client asked for
+	Targets        []string // Model IDs an alias in Model resolves to
+	Fallback       bool     // Model stands in for ClientModel, which no account could serve
+	ClientFormat   string   // API format the client speaks
+	UpstreamFormat string   // API format sent upstream (differs when translating)
+	Number         int      // 1-based attempt number within the request
+	Started        time.Time
+	Tracked        bool // Usage already recorded (failed attempt handed back as the final response)
+	HideUsage      bool // include_usage was added by the proxy, so the usage chunk isn't the client's
+	ClientKeyID    uint // Client key the request is billed to, 0 for the shared key
+	Capture        bool // Record the upstream exchange in the capture store
+	CaptureLimit   int  // Captures to keep when pruning
+}
+
+// failoverTransport sends proxied requests upstream. After a 429, 503/529 or a
+// connection error it replays the buffered body against the next eligible
+// account, until the pool is exhausted or the attempt limit is reached.
+type failoverTransport struct {
+	server *Server
+	base   http.RoundTripper
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s := t.server
+	info, ok := req.Context().Value(requestInfoKey).(*requestInfo)
+	if !ok {
+		return nil, errors.New("missing request info")
+	}
+
+	config := info.Config
+	route := info.routeRequest()
+	route.ModelFallback = config.CrossProviderFallback
+	if info.ClientKey != nil && len(info.ClientKey.ModelScope()) > 0 {
+		// A model-scoped key must not be served a substitute model
+		route.ModelFallback = false
+	}
+	if info.Format == providers.APIFormatResponses && !config.TranslateResponses {
+		route.Translate = false
+	}
+	if config.SessionAffinity {
+		route.SessionTTL = time.Duration(config.SessionTTL) * time.Second
+		if route.SessionTTL <= 0 {
+			route.SessionTTL = defaultSessionTTL
+		}
+	} else {
+		route.Session = ""
+	}
+	maxAttempts := config.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+
+	// A configured fallback chain takes precedence over serving another
+	// provider's default model, which is only tried once the chain runs out
+	chain := s.fallbackChain(info)
+	modelFallback := route.ModelFallback
+	route.ModelFallback = modelFallback && len(chain) == 0
+
+	// A request may spend this long in the wait queue in total
+	waitDeadline := time.Now().Add(waitQueueTimeout(config))
+
+	var (
+		account  *storage.Account
+		lastResp *http.Response
+		lastErr  error
+		err      error
+	)
+	for number := 1; ; number++ {
+		previous := account
+		if previous == nil && config.WaitQueue && s.queue.depth() > 0 {
+			// Requests already waiting get the next free account first
+			account, err = s.waitForAccount(req.Context(), &route, nil, waitDeadline)
+		} else {
+			account, err = s.acquireAccount(req.Context(), &route, previous)
+		}
+		if previous != nil {
+			route.Exclude = append(route.Exclude, previous.ID)
+		}
+
+		// Move down the model's fallback chain once its pool is exhausted
+		for err != nil && len(chain) > 0 && isPoolExhausted(err) {
+			log.Printf("No account available for %s (%v), falling back to %s", route.Model, err, chain[0])
+			route.Model, route.Targets, route.Exclude = chain[0], nil, nil
+			chain = chain[1:]
+			route.ModelFallback = modelFallback && len(chain) == 0
+			account, err = s.acquireAccount(req.Context(), &route, nil)
+		}
+
+		// Rather than fail, wait for an account to come back out of cooldown.
+		// Accounts already tried are eligible again once they do.
+		if err != nil && config.WaitQueue && isWaitable(err) {
+			route.Exclude = nil
+			account, err = s.waitForAccount(req.Context(), &route, err, waitDeadline)
+		}
+		if err != nil {
+			// Pool exhausted - hand back the last upstream failure if there was one
+			if lastResp != nil {
+				return lastResp, nil
+			}
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w (last upstream error: %v)", err, lastErr)
+			}
+			return nil, err
+		}
+
+		if lastResp != nil {
+			discardResponse(lastResp)
+			lastResp = nil
+		}
+
+		attempt := &upstreamAttempt{
+			Account:        account,
+			Model:          route.Model,
+			ClientModel:    info.Model,
+			Targets:        route.Targets,
+			Fallback:       route.Model != info.Model || info.Rerouted,
+			ClientFormat:   info.Format,
+			UpstreamFormat: info.Format,
+			Number:         number,
+			Started:        time.Now(),
+			ClientKeyID:    info.clientKeyID(),
+			Capture:        config.CaptureEnabled,
+			CaptureLimit:   config.CaptureLimit,
+		}
+		resp, err := t.send(req, info, attempt)
+		s.releaseWhenDone(account.ID, resp)
+		s.recordCircuit(req, account, resp, err)
+		s.observeAttempt(req, attempt, resp, err)
+		if err == nil && !isRetryableStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		// Record the failed attempt now so cooldowns apply before the next pick
+		if err != nil {
+			log.Printf("Attempt %d via account %d failed: %v", number, account.ID, err)
+			s.quotaTracker.Record(storage.QuotaHistory{
+				AccountID:     account.ID,
+				RequestsCount: 1,
+				Model:         attempt.Model,
+				StatusCode:    http.StatusBadGateway,
+				Success:       false,
+				Attempt:       number,
+				ClientKeyID:   info.clientKeyID(),
+			})
+		} else {
+			log.Printf("Attempt %d via account %d returned %d", number, account.ID, resp.StatusCode)
+			s.trackResponse(resp, attempt)
+			attempt.Tracked = true
+		}
+
+		// Stop if the client went away or we're out of attempts
+		if req.Context().Err() != nil || number >= maxAttempts {
+			return resp, err
+		}
+
+		lastResp, lastErr = resp, err
+	}
+}
+
+// acquireAccount selects an account (the one after previous, if set) and claims
+// it for the request. If another request claimed what was left in the
+// meantime, the account is skipped and selection runs again. When every
+// account is paced, it waits up to maxPaceDelay for the first one to refill.
+func (s *Server) acquireAccount(ctx context.Context, route *RouteRequest, previous *storage.Account) (*storage.Account, error) {
+	for {
+		var (
+			account *storage.Account
+			err     error
+		)
+		if previous == nil {
+			account, err = s.selectAccount(*route)
+		} else {
+			account, err = s.router.SelectNextAccount(previous, *route)
+		}
+		var paced *pacedError
+		if errors.As(err, &paced) && paced.Wait <= maxPaceDelay {
+			select {
+			case <-time.After(paced.Wait):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if s.claimAccount(account) {
+			return account, nil
+		}
+		route.Exclude = append(route.Exclude, account.ID)
+	}
+}
+
+// claimAccount reserves what a request to the account uses up: a concurrency
+// slot, one request from its rate-limit budget and, if its circuit isn't
+// closed, the probe. It claims nothing if any of them is unavailable.
+func (s *Server) claimAccount(account *storage.Account) bool {
+	now := time.Now()
+	if !s.router.inFlight.acquire(account.ID, account.MaxConcurrent) {
+		return false
+	}
+	ok, probe := s.router.breakers.acquire(account.ID, now)
+	if !ok {
+		s.router.inFlight.release(account.ID)
+		return false
+	}
+	if !s.router.pacer.take(account, now) {
+		if probe != nil {
+			s.router.breakers.abandon(account.ID)
+		}
+		s.router.inFlight.release(account.ID)
+		return false
+	}
+
+	if probe != nil {
+		log.Printf("Circuit half-open for account %d - sending probe request", account.ID)
+		s.saveCircuit(account.ID, probe)
+	}
+	return true
+}
+
+// releaseWhenDone frees the account's concurrency slot once the response body
+// is closed, or right away if there is no response
+func (s *Server) releaseWhenDone(accountID uint, resp *http.Response) {
+	release := func() { s.router.inFlight.release(accountID) }
+	if resp == nil || resp.Body == nil {
+		release()
+		return
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+}
+
+// send prepares a copy of the request for the attempt's account and sends it
+func (t *failoverTransport) send(req *http.Request, info *requestInfo, attempt *upstreamAttempt) (*http.Response, error) {
+	account := attempt.Account
+
+	// Get provider and its endpoint
+	provider := providers.GetProviderForAccount(account)
+	if provider == nil {
+		return nil, fmt.Errorf("provider not found: %s", account.Provider)
+	}
+
+	target, err := url.Parse(provider.GetBaseURL())
+	if err != nil {
+		return nil, fmt.Errorf("invalid provider URL: %w", err)
+	}
+
+	// Resolve a model alias to the account's own model ID, or substitute the
+	// account's default model when it was picked as a cross-provider fallback
+	path, body := info.Path, info.Body
+	if target := aliasTarget(account, attempt.Targets); target != "" {
+		attempt.Model = target
+	} else if attempt.Model != "" && !providers.AccountServesModel(account, attempt.Model) {
+		attempt.Model = providers.DefaultModelForAccount(account)
+		attempt.Fallback = true
+		log.Printf("Account %d doesn't serve %s, falling back to %s", account.ID, attempt.ClientModel, attempt.Model)
+	}
+	if attempt.Model != attempt.ClientModel && providers.SupportsAPIFormat(provider, info.Format) {
+		if path, body, err = rewriteModel(path, body, attempt.Model); err != nil {
+			return nil, fmt.Errorf("failed to rewrite model: %w", err)
+		}
+	}
+
+	// Translate the request when the account speaks a different API format
+	if info.Translatable && !providers.SupportsAPIFormat(provider, info.Format) {
+		attempt.UpstreamFormat = provider.GetAPIFormat()
+		body, err = translator.TranslateRequest(info.Format, attempt.UpstreamFormat, body, attempt.Model, info.Stream)
+		if err != nil {
+			return nil, fmt.Errorf("failed to translate request to %s: %w", attempt.UpstreamFormat, err)
+		}
+		path = upstreamChatPath(attempt.UpstreamFormat, attempt.Model, info.Stream)
+	}
+
+	// OpenAI streams only report usage when asked to
+	if info.Stream && path == "/v1/chat/completions" && attempt.UpstreamFormat == providers.APIFormatOpenAI {
+		var added bool
+		body, added = requestStreamUsage(body)
+		attempt.HideUsage = added && attempt.ClientFormat == providers.APIFormatOpenAI
+	}
+
+	outreq := req.Clone(context.WithValue(req.Context(), attemptKey, attempt))
+	outreq.URL.Scheme = target.Scheme
+	outreq.URL.Host = target.Host
+	// Strip any explicit provider prefix, then map onto the provider's API layout
+	outreq.URL.Path = provider.GetUpstreamPath(path)
+	outreq.URL.RawPath = ""
+	outreq.Host = target.Host
+	// Let the transport negotiate compression so bodies can be inspected and translated
+	outreq.Header.Del("Accept-Encoding")
+	outreq.Header.Del(sessionHeader)
+	stripClientCredentials(outreq)
+	if attempt.UpstreamFormat != attempt.ClientFormat {
+		outreq.Header.Set("Content-Type", "application/json")
+		// Query parameters belong to the client's API (e.g. Gemini's key and alt)
+		outreq.URL.RawQuery = ""
+		if attempt.UpstreamFormat == providers.APIFormatGemini && info.Stream {
+			outreq.URL.RawQuery = "alt=sse"
+		}
+	}
+
+	// Replay the buffered body on every attempt
+	if body != nil {
+		outreq.Body = io.NopCloser(bytes.NewReader(body))
+		outreq.ContentLength = int64(len(body))
+		outreq.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	// Authenticate request using the attempt's account credentials
+	if err := provider.AuthenticateRequest(outreq, account); err != nil {
+		return nil, fmt.Errorf("failed to authenticate upstream request: %w", err)
+	}
+
+	resp, err := t.base.RoundTrip(outreq)
+	if attempt.Capture {
+		resp = t.server.captureExchange(outreq, path, body, attempt, resp, err)
+	}
+	return resp, err
+}
+
+// loadProxyConfig reads the current proxy settings, falling back to defaults
+func (s *Server) loadProxyConfig() storage.ProxyConfig {
+	proxyConfig := storage.ProxyConfig{MaxAttempts: defaultMaxAttempts}
+	if err := s.db.First(&proxyConfig).Error; err != nil {
+		log.Printf("Failed to load proxy config, using defaults: %v", err)
+	}
+	return proxyConfig
+}
+
+// isRetryableStatus reports whether another account might succeed where this one failed
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, 529: // 529 = Anthropic overloaded
+		return true
+	}
+	return false
+}
+
+// discardResponse drains and closes a response that won't be returned to the client
+func discardResponse(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+}
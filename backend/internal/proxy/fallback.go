@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"errors"
+	"log"
+	"quotio-electron-go/backend/internal/storage"
+	"sync"
+)
+
+// fallbackHeader tells the client which model served the request when it
+// wasn't the one it asked for
+const fallbackHeader = "X-Quotio-Fallback-Model"
+
+// fallbackCache holds the fallback chains in memory, so a request that runs out
+// of accounts doesn't query the database for its chain. It is loaded on first
+// use and again after each invalidate.
+type fallbackCache struct {
+	mu     sync.RWMutex
+	loaded bool
+	chains map[string][]string // Model to the fallback models tried in order
+}
+
+func newFallbackCache() *fallbackCache {
+	return &fallbackCache{}
+}
+
+// lookup returns the fallback models configured for a model, or nil if it has
+// no chain. The returned slice is shared and must not be modified.
+func (c *fallbackCache) lookup(model string) ([]string, error) {
+	c.mu.RLock()
+	if c.loaded {
+		defer c.mu.RUnlock()
+		return c.chains[model], nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.loaded {
+		chains, err := storage.GetFallbackChains()
+		if err != nil {
+			return nil, err
+		}
+		c.chains = make(map[string][]string, len(chains))
+		for _, chain := range chains {
+			c.chains[chain.Model] = chain.Models()
+		}
+		c.loaded = true
+	}
+	return c.chains[model], nil
+}
+
+// invalidate drops the loaded chains so the next request reads them afresh
+func (c *fallbackCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loaded = false
+	c.chains = nil
+}
+
+// InvalidateFallbackChains makes the proxy reload the fallback chains, after one
+// is created, updated or deleted
+func (s *Server) InvalidateFallbackChains() {
+	s.fallbacks.invalidate()
+}
+
+// fallbackChain returns the models to move on to, in order, once no account can
+// serve the requested model. A model-scoped client key only falls back to
+// models within its scope.
+func (s *Server) fallbackChain(info *requestInfo) []string {
+	if info.Model == "" {
+		return nil
+	}
+
+	models, err := s.fallbacks.lookup(info.Model)
+	if err != nil {
+		log.Printf("Failed to look up fallback chain for %s: %v", info.Model, err)
+		return nil
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	// The chain is shared with other requests, so filtering builds new slices
+	if info.ClientKey != nil {
+		if scope := info.ClientKey.ModelScope(); len(scope) > 0 {
+			var allowed []string
+			for _, model := range models {
+				if containsString(scope, model) {
+					allowed = append(allowed, model)
+				}
+			}
+			models = allowed
+		}
+	}
+
+	// Skip models the prompt is already known not to fit
+	var fitting []string
+	for _, model := range models {
+		if fitsContext(model, "", info.PromptTokens) {
+			fitting = append(fitting, model)
+		}
+	}
+	return fitting
+}
+
+// isPoolExhausted reports whether selection failed because every account that
+// serves the model is unavailable right now, so another model might still be served
+func isPoolExhausted(err error) bool {
+	return errors.Is(err, ErrNoAccounts) ||
+		errors.Is(err, ErrModelNotServed) ||
+		errors.Is(err, ErrAccountsSaturated) ||
+		errors.Is(err, ErrCircuitOpen) ||
+		errors.Is(err, ErrAccountsPaced)
+}
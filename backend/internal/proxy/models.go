@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"sort"
+	"time"
+)
+
+// modelsPath is answered by the proxy itself rather than forwarded upstream
+const modelsPath = "/v1/models"
+
+// isModelListRequest reports whether the request asks for the model list
+func isModelListRequest(r *http.Request, info *requestInfo) bool {
+	return r.Method == http.MethodGet && info.Path == modelsPath
+}
+
+// availableModels returns the union of models served by the accounts a request
+// may use, sorted by ID. An account offers its ModelAccess list if it has one,
+// otherwise the catalog models its provider serves. Like routing, it only counts
+// active accounts and ones whose cooldown has passed, so a model isn't listed
+// while nothing can serve it.
+func (s *Server) availableModels(info *requestInfo) ([]providers.ModelInfo, error) {
+	var accounts []storage.Account
+	query := s.db.Where("status = ? OR (status = ? AND cooldown_until < ?)", "active", "cooldown", time.Now())
+	if info.Provider != "" {
+		query = query.Where("provider = ?", info.Provider)
+	}
+	if err := query.Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	var providerScope, modelScope []string
+	if info.ClientKey != nil {
+		providerScope = info.ClientKey.ProviderScope()
+		modelScope = info.ClientKey.ModelScope()
+	}
+
+	seen := make(map[string]bool)
+	models := make([]providers.ModelInfo, 0)
+	add := func(model providers.ModelInfo) {
+		if seen[model.ID] || (len(modelScope) > 0 && !containsString(modelScope, model.ID)) {
+			return
+		}
+		seen[model.ID] = true
+		models = append(models, model)
+	}
+
+	for i := range accounts {
+		account := &accounts[i]
+		if len(providerScope) > 0 && !containsString(providerScope, account.Provider) {
+			continue
+		}
+
+		allowed := account.AllowedModels()
+		if len(allowed) == 0 {
+			for _, model := range providers.OfferedModels(account.Provider) {
+				add(model)
+			}
+			continue
+		}
+		for _, id := range allowed {
+			model, ok := providers.GetModel(id, account.Provider)
+			if !ok {
+				model = providers.ModelInfo{ID: id, Name: id, Provider: account.Provider}
+			}
+			add(model)
+		}
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].ID < models[j].ID })
+	return models, nil
+}
+
+// wantsAnthropicModels reports whether the client expects Anthropic's model list
+// shape: it sent an anthropic-version header or went through the /claude prefix
+func wantsAnthropicModels(r *http.Request, info *requestInfo) bool {
+	return r.Header.Get("anthropic-version") != "" || info.Provider == "claude"
+}
+
+// serveModels answers /v1/models with the models available across active
+// accounts, so agents see the same list on every call
+func (s *Server) serveModels(w http.ResponseWriter, r *http.Request, info *requestInfo) {
+	models, err := s.availableModels(info)
+	if err != nil {
+		writeProxyError(w, r, http.StatusInternalServerError, "failed to list models")
+		return
+	}
+
+	var body interface{}
+	if wantsAnthropicModels(r, info) {
+		data := make([]map[string]interface{}, 0, len(models))
+		for _, model := range models {
+			data = append(data, map[string]interface{}{
+				"type":         "model",
+				"id":           model.ID,
+				"display_name": model.Name,
+				"created_at":   time.Unix(0, 0).UTC().Format(time.RFC3339),
+			})
+		}
+		list := map[string]interface{}{"data": data, "has_more": false, "first_id": nil, "last_id": nil}
+		if len(models) > 0 {
+			list["first_id"] = models[0].ID
+			list["last_id"] = models[len(models)-1].ID
+		}
+		body = list
+	} else {
+		data := make([]map[string]interface{}, 0, len(models))
+		for _, model := range models {
+			data = append(data, map[string]interface{}{
+				"id":       model.ID,
+				"object":   "model",
+				"created":  0,
+				"owned_by": model.Provider,
+			})
+		}
+		body = map[string]interface{}{"object": "list", "data": data}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(body)
+}
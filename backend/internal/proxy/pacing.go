@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"fmt"
+	"math"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRefillWindow is assumed for a bucket whose provider gave no future
+	// reset time; request and token limits are reported per minute
+	defaultRefillWindow = time.Minute
+	// maxPaceDelay is how long a request may be held back waiting for an
+	// account's bucket to refill before the proxy gives up on it
+	maxPaceDelay = 5 * time.Second
+)
+
+// pacedError is returned when every eligible account is predicted to be out of
+// rate limit. Wait is how long until the first of them can take a request.
+type pacedError struct {
+	Wait time.Duration
+}
+
+func (e *pacedError) Error() string {
+	return fmt.Sprintf("%v for another %s", ErrAccountsPaced, e.Wait.Round(time.Millisecond))
+}
+
+func (e *pacedError) Unwrap() error {
+	return ErrAccountsPaced
+}
+
+// tokenBucket models one upstream rate-limit window. It holds what the provider
+// last reported as remaining and refills so that it is full again at the
+// reported reset time, the way providers replenish their own limits.
+type tokenBucket struct {
+	capacity float64
+	level    float64
+	rate     float64 // Units refilled per second
+	updated  time.Time
+}
+
+// newTokenBucket returns a bucket for the reported window, or nil when the
+// provider reports no limit
+func newTokenBucket(limit, remaining int64, reset time.Time, now time.Time) *tokenBucket {
+	if limit <= 0 {
+		return nil
+	}
+
+	b := &tokenBucket{
+		capacity: float64(limit),
+		level:    math.Min(math.Max(float64(remaining), 0), float64(limit)),
+		updated:  now,
+	}
+	if window := reset.Sub(now); window > 0 {
+		b.rate = math.Max(b.capacity-b.level, 1) / window.Seconds()
+	} else {
+		// The reported window is over, so the limit is back to full
+		b.level = b.capacity
+		b.rate = b.capacity / defaultRefillWindow.Seconds()
+	}
+	return b
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.level = math.Min(b.capacity, b.level+elapsed*b.rate)
+		b.updated = now
+	}
+}
+
+// wait returns how long until the bucket holds at least one unit
+func (b *tokenBucket) wait(now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	if b.level >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.level) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) take(n float64) {
+	if b != nil {
+		b.level -= n
+	}
+}
+
+// accountBuckets paces one account against both of its upstream limits
+type accountBuckets struct {
+	requests *tokenBucket
+	tokens   *tokenBucket // Only gates on being empty; headers resync it after each response
+}
+
+// wait returns how long until the account can take another request
+func (a *accountBuckets) wait(now time.Time) time.Duration {
+	return max(a.requests.wait(now), a.tokens.wait(now))
+}
+
+// ratePacer predicts each account's upstream rate limits from the headers it
+// last reported, so requests are held back or routed elsewhere before the
+// provider answers 429 and the account is put into cooldown
+type ratePacer struct {
+	mu       sync.Mutex
+	accounts map[uint]*accountBuckets
+}
+
+func newRatePacer() *ratePacer {
+	return &ratePacer{accounts: make(map[uint]*accountBuckets)}
+}
+
+// observe reseeds the account's buckets from freshly parsed rate-limit headers.
+// Responses that report no limits leave the current prediction alone.
+func (p *ratePacer) observe(accountID uint, limits *providers.RateLimitInfo, now time.Time) {
+	if limits.RequestsLimit <= 0 && limits.TokensLimit <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.accounts[accountID] = &accountBuckets{
+		requests: newTokenBucket(limits.RequestsLimit, limits.RequestsRemaining, limits.RequestsReset, now),
+		tokens:   newTokenBucket(limits.TokensLimit, limits.TokensRemaining, limits.TokensReset, now),
+	}
+}
+
+// buckets returns the account's buckets, seeding them from the limits stored on
+// the account the first time it is seen, such as after a restart
+func (p *ratePacer) buckets(account *storage.Account, now time.Time) *accountBuckets {
+	buckets, ok := p.accounts[account.ID]
+	if !ok {
+		buckets = &accountBuckets{
+			requests: newTokenBucket(account.RateLimitRequests, account.RateLimitRequestsRemaining, account.RateLimitRequestsReset, now),
+			tokens:   newTokenBucket(account.RateLimitTokens, account.RateLimitTokensRemaining, account.RateLimitTokensReset, now),
+		}
+		p.accounts[account.ID] = buckets
+	}
+	return buckets
+}
+
+// wait returns how long until the account is predicted to accept a request, 0 if it can now
+func (p *ratePacer) wait(account *storage.Account, now time.Time) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.buckets(account, now).wait(now)
+}
+
+// take claims one request from the account's buckets, unless they are empty
+func (p *ratePacer) take(account *storage.Account, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	buckets := p.buckets(account, now)
+	if buckets.wait(now) > 0 {
+		return false
+	}
+	buckets.requests.take(1)
+	return true
+}
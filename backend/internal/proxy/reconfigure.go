@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"quotio-electron-go/backend/internal/storage"
+	"time"
+)
+
+// rebindDrainTimeout is how long requests still running on the old port after a
+// port change, streams included, get to finish before their connections are closed
+const rebindDrainTimeout = 5 * time.Minute
+
+// ErrPortUnavailable is returned by Reconfigure when the new port can't be bound
+var ErrPortUnavailable = errors.New("proxy port unavailable")
+
+// Reconfigure applies new settings to the proxy without a restart. Keys and the
+// routing strategy are read from one ProxyConfig snapshot per request, so they
+// already switch over together; this also makes the strategy the router's
+// default and, if the port changed, moves the listener to it.
+//
+// The new port is bound first and save, which persists the settings, runs only
+// once it is. If either fails, the listener is closed and the proxy keeps its
+// old strategy and port. Reconfigure returns save's error as is and wraps
+// ErrPortUnavailable when the port can't be bound.
+func (s *Server) Reconfigure(config storage.ProxyConfig, save func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	port := s.port
+	if config.Port != 0 {
+		port = config.Port
+	}
+
+	var listener net.Listener
+	if s.running && port != s.port {
+		var err error
+		listener, err = net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			return fmt.Errorf("%w: failed to listen on port %d: %v", ErrPortUnavailable, port, err)
+		}
+	}
+
+	if err := save(); err != nil {
+		if listener != nil {
+			listener.Close()
+		}
+		return err
+	}
+
+	if config.RoutingStrategy != "" && config.RoutingStrategy != s.router.Strategy() {
+		s.router.SetStrategy(config.RoutingStrategy)
+		log.Printf("Proxy routing strategy changed to %s", config.RoutingStrategy)
+	}
+	if listener != nil {
+		s.rebind(listener, port)
+	} else {
+		s.port = port
+	}
+	return nil
+}
+
+// Port returns the port the proxy listens on, or will listen on once started
+func (s *Server) Port() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.port
+}
+
+// rebind starts serving on the new port's listener, then shuts the old server
+// down gracefully: it stops accepting connections right away and is closed once
+// its in-flight requests have completed or rebindDrainTimeout runs out. The
+// caller must hold s.mu.
+func (s *Server) rebind(listener net.Listener, port int) {
+	old, oldPort := s.server, s.port
+	s.server = s.newHTTPServer(port)
+	s.port = port
+	go s.serve(s.server, listener, port)
+
+	go drainServer(old, oldPort)
+}
+
+// drainServer waits for a replaced server's requests to finish, then closes it
+func drainServer(server *http.Server, port int) {
+	log.Printf("Proxy moved off port %d, draining in-flight requests", port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), rebindDrainTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Requests on port %d didn't finish in %s, closing them: %v", port, rebindDrainTimeout, err)
+		server.Close()
+		return
+	}
+	log.Printf("Stopped listening on port %d", port)
+}
@@ -0,0 +1,234 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/storage"
+	"regexp"
+	"strings"
+)
+
+// contextKey namespaces values stored on proxied request contexts
+type contextKey string
+
+const (
+	requestInfoKey contextKey = "requestInfo"
+	attemptKey     contextKey = "attempt"
+)
+
+// geminiPathPattern matches Gemini model action paths such as
+// /v1beta/models/gemini-1.5-pro:streamGenerateContent
+var geminiPathPattern = regexp.MustCompile(`^/v1(?:beta|alpha)?/models/([^/:]+):([A-Za-z]+)$`)
+
+// providerPrefixAliases maps path prefixes that aren't provider names to providers
+var providerPrefixAliases = map[string]string{
+	"anthropic": "claude",
+	"google":    "gemini",
+}
+
+// requestInfo holds what the proxy learned about an incoming request
+type requestInfo struct {
+	Path         string   // Request path with any provider prefix removed
+	Provider     string   // Provider pinned by an explicit path prefix (e.g. /claude/...)
+	Format       string   // API format inferred from the path
+	Translatable bool     // Generation endpoint that an upstream in another format can serve
+	Model        string   // Model ID from the JSON body or Gemini path, if any
+	Stream       bool     // Whether the client asked for a streaming response
+	Session      string   // Conversation key for sticky routing, if one could be derived
+	Targets      []string // Model IDs that Model resolves to when it is an alias
+	PromptTokens int      // Estimated prompt size, 0 if it wasn't checked against a context window
+	Rerouted     bool     // Targets are larger-context models standing in for Model
+	Body         []byte   // Buffered request body
+
+	ClientKey *storage.ClientKey  // Client key that authenticated the request, nil for the shared key
+	Cache     *cacheLookup        // Set when the response may be stored in the response cache
+	Config    storage.ProxyConfig // Settings the request was authenticated with, used for all of its routing
+}
+
+// routeRequest converts the parsed request into router constraints
+func (info *requestInfo) routeRequest() RouteRequest {
+	route := RouteRequest{
+		Model:     info.Model,
+		Provider:  info.Provider,
+		Format:    info.Format,
+		Translate: info.Translatable,
+		Session:   info.Session,
+		Targets:   info.Targets,
+		Strategy:  info.Config.RoutingStrategy,
+	}
+	if info.ClientKey != nil {
+		route.AllowedProviders = info.ClientKey.ProviderScope()
+	}
+	return route
+}
+
+// clientKeyID returns the ID of the client key that made the request, 0 if none
+func (info *requestInfo) clientKeyID() uint {
+	if info.ClientKey == nil {
+		return 0
+	}
+	return info.ClientKey.ID
+}
+
+// parseRequestInfo buffers the request body and extracts routing hints from it.
+// The body is restored on the request so it can still be forwarded upstream.
+func parseRequestInfo(r *http.Request) (*requestInfo, error) {
+	info := &requestInfo{}
+	info.Provider, info.Path = splitProviderPrefix(r.URL.Path)
+	info.Format = inferAPIFormat(info.Path)
+	info.Translatable = isGenerationPath(info.Path)
+
+	// Gemini carries the model and streaming mode in the path, not the body
+	if m := geminiPathPattern.FindStringSubmatch(info.Path); m != nil {
+		info.Model = m[1]
+		info.Stream = m[2] == "streamGenerateContent"
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return info, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	info.Body = body
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	// Only JSON bodies carry a model field
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return info, nil
+	}
+
+	var payload struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	if err := json.Unmarshal(trimmed, &payload); err == nil {
+		if model := strings.TrimSpace(payload.Model); model != "" {
+			info.Model = model
+		}
+		info.Stream = info.Stream || payload.Stream
+	}
+	if info.Translatable {
+		info.Session = sessionKey(r.Header, trimmed)
+	}
+
+	return info, nil
+}
+
+// splitProviderPrefix detects an explicit provider prefix such as /claude/v1/messages
+// and returns the pinned provider along with the remaining path
+func splitProviderPrefix(path string) (string, string) {
+	trimmed := strings.TrimPrefix(path, "/")
+	segment, rest, _ := strings.Cut(trimmed, "/")
+
+	name := strings.ToLower(segment)
+	if alias, ok := providerPrefixAliases[name]; ok {
+		name = alias
+	}
+	if providers.GetProvider(name) == nil {
+		return "", path
+	}
+
+	return name, "/" + rest
+}
+
+// isGenerationPath reports whether the path is a chat/generation endpoint that
+// the translator can convert between API formats
+func isGenerationPath(path string) bool {
+	if path == "/v1/chat/completions" || path == "/v1/messages" || path == "/v1/responses" {
+		return true
+	}
+	if m := geminiPathPattern.FindStringSubmatch(path); m != nil {
+		return m[2] == "generateContent" || m[2] == "streamGenerateContent"
+	}
+	return false
+}
+
+// inferAPIFormat infers the API format a client speaks from the request path
+func inferAPIFormat(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/v1/messages"):
+		return providers.APIFormatAnthropic
+	case strings.HasPrefix(path, "/v1/chat/completions"),
+		strings.HasPrefix(path, "/v1/completions"),
+		strings.HasPrefix(path, "/v1/embeddings"):
+		return providers.APIFormatOpenAI
+	case strings.HasPrefix(path, "/v1/responses"):
+		return providers.APIFormatResponses
+	case geminiPathPattern.MatchString(path):
+		return providers.APIFormatGemini
+	}
+	return ""
+}
+
+// rewriteModel points a request at a different model, in the Gemini path
+// or in the JSON body's model field, leaving everything else untouched
+func rewriteModel(path string, body []byte, model string) (string, []byte, error) {
+	if m := geminiPathPattern.FindStringSubmatchIndex(path); m != nil {
+		path = path[:m[2]] + model + path[m[3]:]
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return path, body, nil
+	}
+
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &payload); err != nil {
+		return path, nil, err
+	}
+	if _, ok := payload["model"]; !ok {
+		return path, body, nil
+	}
+
+	encoded, err := json.Marshal(model)
+	if err != nil {
+		return path, nil, err
+	}
+	payload["model"] = encoded
+	body, err = json.Marshal(payload)
+	return path, body, err
+}
+
+// requestStreamUsage turns on stream_options.include_usage for an OpenAI Chat
+// Completions stream so it can be metered. It reports whether the option was
+// added on the client's behalf, in which case the usage chunk should be hidden.
+func requestStreamUsage(body []byte) ([]byte, bool) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return body, false
+	}
+
+	var options map[string]json.RawMessage
+	if raw, ok := payload["stream_options"]; ok {
+		if err := json.Unmarshal(raw, &options); err != nil {
+			return body, false
+		}
+	}
+	if options == nil {
+		options = make(map[string]json.RawMessage)
+	}
+	if string(options["include_usage"]) == "true" {
+		return body, false
+	}
+
+	options["include_usage"] = json.RawMessage("true")
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return body, false
+	}
+	payload["stream_options"] = encoded
+	updated, err := json.Marshal(payload)
+	if err != nil {
+		return body, false
+	}
+	return updated, true
+}
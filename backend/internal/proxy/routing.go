@@ -2,7 +2,13 @@ package proxy
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"quotio-electron-go/backend/internal/providers"
 	"quotio-electron-go/backend/internal/storage"
+	"quotio-electron-go/backend/internal/translator"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -11,18 +17,161 @@ import (
 
 type Router struct {
 	db              *gorm.DB
-	strategy        string
+	strategy        atomic.Value // Default strategy for requests that don't name one
 	roundRobinIndex uint64
+	stats           *healthStats     // Live latency and error rates for the adaptive strategy
+	affinity        *affinityTable   // Sticky session -> account pins
+	inFlight        *inFlightCounter // Requests each account is serving right now
+	pacer           *ratePacer       // Predicted upstream rate limits per account
+	breakers        *circuitBreakers // Keeps persistently failing accounts out of rotation
 }
 
 func NewRouter(db *gorm.DB, strategy string) *Router {
-	return &Router{
+	stats := newHealthStats()
+	stats.seed(db)
+	breakers := newCircuitBreakers()
+	breakers.seed(db)
+	r := &Router{
 		db:       db,
-		strategy: strategy,
+		stats:    stats,
+		affinity: newAffinityTable(),
+		inFlight: newInFlightCounter(),
+		pacer:    newRatePacer(),
+		breakers: breakers,
 	}
+	r.strategy.Store(strategy)
+	return r
 }
 
-func (r *Router) SelectAccount() (*storage.Account, error) {
+// Strategy returns the router's default routing strategy
+func (r *Router) Strategy() string {
+	return r.strategy.Load().(string)
+}
+
+// SetStrategy changes the default routing strategy used from the next selection on
+func (r *Router) SetStrategy(strategy string) {
+	r.strategy.Store(strategy)
+}
+
+// Routing strategies understood by the router
+const (
+	StrategyRoundRobin    = "round_robin"
+	StrategyFillFirst     = "fill_first"
+	StrategyPriority      = "priority"
+	StrategyWeighted      = "weighted"
+	StrategyAdaptive      = "adaptive"
+	StrategyMostRemaining = "most_remaining"
+)
+
+// RoutingStrategies lists every valid strategy name
+var RoutingStrategies = []string{
+	StrategyRoundRobin,
+	StrategyFillFirst,
+	StrategyPriority,
+	StrategyWeighted,
+	StrategyAdaptive,
+	StrategyMostRemaining,
+}
+
+// IsValidStrategy reports whether name is a known routing strategy
+func IsValidStrategy(name string) bool {
+	for _, strategy := range RoutingStrategies {
+		if strategy == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteRequest describes what an incoming request needs from an account
+type RouteRequest struct {
+	Model     string // Requested model ID, empty if the request doesn't name one
+	Provider  string // Provider pinned by the client, empty for any
+	Format    string // API format the client speaks, empty for any
+	Translate bool   // Accounts speaking another format are eligible if the translator supports it
+	Exclude   []uint // Accounts already tried for this request
+	Strategy  string // Routing strategy to pick with, empty for the router's default
+
+	// Targets are the model IDs an alias in Model resolves to. Accounts offering
+	// any of them are eligible; empty when Model isn't an alias.
+	Targets []string
+
+	// AllowedProviders limits routing to these providers (a client key's scope), empty for any
+	AllowedProviders []string
+
+	// ModelFallback lets accounts of other providers serve the request with their
+	// default model while the accounts that offer the requested one are cooling down
+	ModelFallback bool
+
+	// Session keys the conversation for sticky routing, empty to route freely.
+	// SessionTTL is how long an idle conversation stays pinned to its account.
+	Session    string
+	SessionTTL time.Duration
+}
+
+var (
+	// ErrNoAccounts is returned when no account is active at all
+	ErrNoAccounts = errors.New("no active accounts available")
+	// ErrModelNotServed is returned when active accounts exist but none can serve the model
+	ErrModelNotServed = errors.New("no active account can serve the requested model")
+	// ErrNoCompatibleAccount is returned when no active account matches the pinned provider or API format
+	ErrNoCompatibleAccount = errors.New("no active account for the requested provider")
+	// ErrAccountsSaturated is returned when every eligible account is at its MaxConcurrent limit
+	ErrAccountsSaturated = errors.New("all eligible accounts are at their concurrency limit")
+	// ErrCircuitOpen is returned when every eligible account's circuit breaker is open
+	ErrCircuitOpen = errors.New("all eligible accounts are failing upstream and temporarily out of rotation")
+	// ErrAccountsPaced is returned when every eligible account is predicted to be out of rate limit
+	ErrAccountsPaced = errors.New("all eligible accounts are predicted to be rate limited")
+)
+
+func (r *Router) SelectAccount(req RouteRequest) (*storage.Account, error) {
+	accounts, err := r.candidateAccounts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.selectForSession(accounts, req)
+}
+
+// SelectNextAccount tries to select the next valid account
+func (r *Router) SelectNextAccount(excludeAccount *storage.Account, req RouteRequest) (*storage.Account, error) {
+	req.Exclude = append(append([]uint(nil), req.Exclude...), excludeAccount.ID)
+	accounts, err := r.candidateAccounts(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.selectForSession(accounts, req)
+}
+
+// selectForSession keeps a conversation on its pinned account for as long as that
+// account is still a candidate. Otherwise it picks by strategy and pins the result,
+// so a conversation moves only when its account becomes unavailable.
+func (r *Router) selectForSession(accounts []storage.Account, req RouteRequest) (*storage.Account, error) {
+	if req.Session == "" {
+		return r.selectByStrategy(accounts, req.Strategy)
+	}
+
+	if id, ok := r.affinity.lookup(req.Session); ok {
+		for i := range accounts {
+			if accounts[i].ID == id {
+				r.affinity.pin(req.Session, id, req.SessionTTL)
+				return &accounts[i], nil
+			}
+		}
+	}
+
+	account, err := r.selectByStrategy(accounts, req.Strategy)
+	if err != nil {
+		return nil, err
+	}
+	r.affinity.pin(req.Session, account.ID, req.SessionTTL)
+	return account, nil
+}
+
+// candidateAccounts loads routable accounts that can serve the request,
+// leaving out any accounts listed in req.Exclude
+func (r *Router) candidateAccounts(req RouteRequest) ([]storage.Account, error) {
 	var accounts []storage.Account
 	now := time.Now()
 
@@ -31,6 +180,9 @@ func (r *Router) SelectAccount() (*storage.Account, error) {
 		"status = ? OR (status = ? AND cooldown_until < ?)",
 		"active", "cooldown", now,
 	)
+	if len(req.Exclude) > 0 {
+		query = query.Where("id NOT IN ?", req.Exclude)
+	}
 
 	if err := query.Find(&accounts).Error; err != nil {
 		return nil, err
@@ -45,52 +197,145 @@ func (r *Router) SelectAccount() (*storage.Account, error) {
 	}
 
 	if len(accounts) == 0 {
-		return nil, errors.New("no active accounts available")
+		if err := r.coolingDown(req, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoAccounts
 	}
 
-	switch r.strategy {
-	case "round_robin":
-		return r.selectRoundRobin(accounts)
-	case "fill_first":
-		return r.selectFillFirst(accounts)
-	default:
-		return r.selectRoundRobin(accounts)
+	// Only keep accounts of the pinned (and allowed) providers that speak the client's API format
+	if req.Provider != "" || req.Format != "" || len(req.AllowedProviders) > 0 {
+		filtered := accounts[:0]
+		for _, account := range accounts {
+			if accountMatchesRoute(&account, req) {
+				filtered = append(filtered, account)
+			}
+		}
+		if len(filtered) == 0 {
+			if err := r.coolingDown(req, now); err != nil {
+				return nil, err
+			}
+			if req.Provider != "" {
+				return nil, fmt.Errorf("%w: %s", ErrNoCompatibleAccount, req.Provider)
+			}
+			if len(req.AllowedProviders) > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrNoCompatibleAccount, strings.Join(req.AllowedProviders, ", "))
+			}
+			return nil, fmt.Errorf("%w: %s API", ErrNoCompatibleAccount, req.Format)
+		}
+		accounts = filtered
 	}
-}
 
-// SelectNextAccount tries to select the next valid account
-func (r *Router) SelectNextAccount(excludeAccount *storage.Account) (*storage.Account, error) {
-	var accounts []storage.Account
-	now := time.Now()
+	// Only keep accounts whose provider (and model access list) covers the model
+	if req.Model != "" {
+		filtered := accounts[:0]
+		for _, account := range accounts {
+			if aliasTarget(&account, req.Targets) != "" || providers.AccountServesModel(&account, req.Model) {
+				filtered = append(filtered, account)
+			}
+		}
+		if len(filtered) == 0 {
+			cooling := r.coolingDown(req, now)
+			if cooling == nil {
+				return nil, fmt.Errorf("%w: %s", ErrModelNotServed, req.Model)
+			}
+			// Fall back only while the model's own accounts are cooling down, not
+			// for models that no account offers at all
+			if req.ModelFallback && req.Translate {
+				for _, account := range accounts {
+					if providers.DefaultModelForAccount(&account) != "" {
+						filtered = append(filtered, account)
+					}
+				}
+			}
+			if len(filtered) == 0 {
+				return nil, cooling
+			}
+		}
+		accounts = filtered
+	}
 
-	// Include active accounts and cooldown accounts that have passed their reset time
-	// Exclude the current account to find a backup
-	query := r.db.Where(
-		"(status = ? OR (status = ? AND cooldown_until < ?)) AND id != ?",
-		"active", "cooldown", now, excludeAccount.ID,
-	)
+	// Skip accounts whose circuit is open after repeated upstream failures
+	healthy := accounts[:0]
+	for _, account := range accounts {
+		if r.breakers.available(account.ID, now) {
+			healthy = append(healthy, account)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, ErrCircuitOpen
+	}
+	accounts = healthy
 
-	if err := query.Find(&accounts).Error; err != nil {
-		return nil, err
+	// Skip accounts already running as many requests as they allow
+	available := accounts[:0]
+	for _, account := range accounts {
+		if !r.inFlight.saturated(account.ID, account.MaxConcurrent) {
+			available = append(available, account)
+		}
+	}
+	if len(available) == 0 {
+		return nil, ErrAccountsSaturated
 	}
 
-	// Reactivate accounts that have passed cooldown
-	for i := range accounts {
-		if accounts[i].Status == "cooldown" && time.Now().After(accounts[i].CooldownUntil) {
-			r.db.Model(&accounts[i]).Update("status", "active")
-			accounts[i].Status = "active"
+	// Skip accounts whose upstream limits are predicted to be used up, so the
+	// request goes elsewhere instead of earning a 429
+	ready := available[:0]
+	var wait time.Duration
+	for _, account := range available {
+		d := r.pacer.wait(&account, now)
+		if d == 0 {
+			ready = append(ready, account)
+		} else if wait == 0 || d < wait {
+			wait = d
 		}
 	}
+	if len(ready) == 0 {
+		return nil, &pacedError{Wait: wait}
+	}
 
-	if len(accounts) == 0 {
-		return nil, errors.New("no valid accounts available")
+	return ready, nil
+}
+
+// accountMatchesRoute checks the pinned provider and API format constraints
+func accountMatchesRoute(account *storage.Account, req RouteRequest) bool {
+	if req.Provider != "" && account.Provider != req.Provider {
+		return false
+	}
+	if len(req.AllowedProviders) > 0 && !containsString(req.AllowedProviders, account.Provider) {
+		return false
+	}
+	if req.Format != "" {
+		provider := providers.GetProviderForAccount(account)
+		if provider == nil {
+			return false
+		}
+		if !providers.SupportsAPIFormat(provider, req.Format) &&
+			!(req.Translate && translator.CanTranslate(req.Format, provider.GetAPIFormat())) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Router) selectByStrategy(accounts []storage.Account, strategy string) (*storage.Account, error) {
+	if strategy == "" {
+		strategy = r.Strategy()
 	}
 
-	switch r.strategy {
-	case "round_robin":
+	switch strategy {
+	case StrategyRoundRobin:
 		return r.selectRoundRobin(accounts)
-	case "fill_first":
+	case StrategyFillFirst:
 		return r.selectFillFirst(accounts)
+	case StrategyPriority:
+		return r.selectPriority(accounts)
+	case StrategyWeighted:
+		return r.selectWeighted(accounts)
+	case StrategyAdaptive:
+		return r.selectAdaptive(accounts)
+	case StrategyMostRemaining:
+		return r.selectMostRemaining(accounts)
 	default:
 		return r.selectRoundRobin(accounts)
 	}
@@ -136,6 +381,130 @@ func (r *Router) selectFillFirst(accounts []storage.Account) (*storage.Account,
 	return &accounts[0], nil
 }
 
+// selectPriority stays on the highest Priority tier that still has quota left,
+// rotating round-robin within the tier. Lower tiers only see traffic once every
+// account above them is exhausted (accounts in cooldown never reach this point).
+func (r *Router) selectPriority(accounts []storage.Account) (*storage.Account, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts available")
+	}
+
+	available := make([]storage.Account, 0, len(accounts))
+	for _, account := range accounts {
+		if hasQuotaRemaining(&account) {
+			available = append(available, account)
+		}
+	}
+	if len(available) == 0 {
+		available = accounts
+	}
+
+	top := available[0].Priority
+	for _, account := range available[1:] {
+		if account.Priority > top {
+			top = account.Priority
+		}
+	}
+
+	tier := available[:0:0]
+	for _, account := range available {
+		if account.Priority == top {
+			tier = append(tier, account)
+		}
+	}
+	return r.selectRoundRobin(tier)
+}
+
+// selectWeighted picks an account at random in proportion to its Weight.
+// Weights below 1 count as 1 so no active account is starved entirely.
+func (r *Router) selectWeighted(accounts []storage.Account) (*storage.Account, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts available")
+	}
+
+	total := 0
+	for i := range accounts {
+		total += accountWeight(&accounts[i])
+	}
+
+	pick := rand.Intn(total)
+	for i := range accounts {
+		pick -= accountWeight(&accounts[i])
+		if pick < 0 {
+			return &accounts[i], nil
+		}
+	}
+	return &accounts[len(accounts)-1], nil
+}
+
+// selectMostRemaining picks the account with the most headroom left under its
+// provider-reported limits, rotating round-robin among equally placed accounts
+func (r *Router) selectMostRemaining(accounts []storage.Account) (*storage.Account, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts available")
+	}
+
+	now := time.Now()
+	headroom := make([]float64, len(accounts))
+	best := 0.0
+	for i := range accounts {
+		headroom[i] = accountHeadroom(&accounts[i], now)
+		if headroom[i] > best {
+			best = headroom[i]
+		}
+	}
+
+	const tolerance = 0.01
+	leaders := accounts[:0:0]
+	for i := range accounts {
+		if headroom[i] >= best-tolerance {
+			leaders = append(leaders, accounts[i])
+		}
+	}
+	return r.selectRoundRobin(leaders)
+}
+
+// headroomResetHorizon is how far ahead a window reset still counts. A window
+// resetting within it is treated as partly refilled already; one resetting later
+// only offers what remains.
+const headroomResetHorizon = time.Minute
+
+// accountHeadroom estimates the share of an account's capacity still available,
+// between 0 and 1, using the tightest of its request, token and quota limits.
+// Accounts that report no limits count as having full headroom.
+func accountHeadroom(account *storage.Account, now time.Time) float64 {
+	headroom := windowHeadroom(account.RateLimitRequests, account.RateLimitRequestsRemaining, account.RateLimitRequestsReset, now)
+	headroom = math.Min(headroom, windowHeadroom(account.RateLimitTokens, account.RateLimitTokensRemaining, account.RateLimitTokensReset, now))
+	if account.QuotaLimit > 0 {
+		headroom = math.Min(headroom, math.Max(float64(account.QuotaLimit-account.QuotaUsed)/float64(account.QuotaLimit), 0))
+	}
+	return headroom
+}
+
+// windowHeadroom returns the remaining share of one rate-limit window, raised
+// towards full the closer the window is to resetting
+func windowHeadroom(limit, remaining int64, reset time.Time, now time.Time) float64 {
+	if limit <= 0 || !reset.After(now) {
+		return 1
+	}
+	fraction := math.Min(math.Max(float64(remaining)/float64(limit), 0), 1)
+	refilled := 1 - math.Min(float64(reset.Sub(now))/float64(headroomResetHorizon), 1)
+	return fraction + (1-fraction)*refilled
+}
+
+// accountWeight returns the account's share for the weighted strategy
+func accountWeight(account *storage.Account) int {
+	if account.Weight < 1 {
+		return 1
+	}
+	return account.Weight
+}
+
+// hasQuotaRemaining reports whether the account's quota is unlimited or not yet used up
+func hasQuotaRemaining(account *storage.Account) bool {
+	return account.QuotaLimit == 0 || account.QuotaUsed < account.QuotaLimit
+}
+
 // RefreshAccountStatus refreshes account status from database
 func (r *Router) RefreshAccountStatus(accountID uint) error {
 	var account storage.Account
@@ -2,15 +2,17 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
-	"net/url"
 	"quotio-electron-go/backend/internal/providers"
 	"quotio-electron-go/backend/internal/quota"
 	"quotio-electron-go/backend/internal/storage"
+	"quotio-electron-go/backend/internal/translator"
 	"strings"
 	"sync"
 	"time"
@@ -19,24 +21,37 @@ import (
 )
 
 type Server struct {
-	db              *gorm.DB
-	port            int
-	routingStrategy string
-	proxy           *httputil.ReverseProxy
-	server          *http.Server
-	running         bool
-	mu              sync.RWMutex
-	router          *Router
-	quotaTracker    *quota.Tracker
+	db           *gorm.DB
+	port         int
+	proxy        *httputil.ReverseProxy
+	server       *http.Server
+	running      bool
+	mu           sync.RWMutex
+	router       *Router
+	quotaTracker *quota.Tracker
+	keyLimiter   *keyRateLimiter
+	cache        *responseCache
+	queue        *waitQueue
+	clientKeys   *clientKeyCache
+	aliases      *aliasCache
+	fallbacks    *fallbackCache
+	keyUsage     *keyTokenUsage
+	stopFlush    chan struct{} // Closed by Stop to end flushHealthLoop
 }
 
 func NewServer(db *gorm.DB, port int, routingStrategy string) *Server {
 	return &Server{
-		db:              db,
-		port:            port,
-		routingStrategy: routingStrategy,
-		router:          NewRouter(db, routingStrategy),
-		quotaTracker:    quota.NewTracker(db),
+		db:           db,
+		port:         port,
+		router:       NewRouter(db, routingStrategy),
+		quotaTracker: quota.NewTracker(db),
+		keyLimiter:   newKeyRateLimiter(),
+		cache:        newResponseCache(),
+		queue:        newWaitQueue(),
+		clientKeys:   newClientKeyCache(),
+		aliases:      newAliasCache(),
+		fallbacks:    newFallbackCache(),
+		keyUsage:     newKeyTokenUsage(),
 	}
 }
 
@@ -49,191 +64,83 @@ func (s *Server) Start() error {
 	}
 
 	// Create reverse proxy
-	director := func(req *http.Request) {
-		// Route request to appropriate provider (with validation)
-		account, err := s.router.SelectAccount()
-		if err != nil {
-			log.Printf("Error selecting account: %v", err)
-			req.URL.Scheme = "http"
-			req.URL.Host = "localhost"
-			req.URL.Path = "/error"
-			return
-		}
-
-		// Pre-request validation: Check if account is valid for routing
-		if !s.isAccountValidForRouting(account) {
-			log.Printf("Account %d not valid for routing (status: %s)", account.ID, account.Status)
-			// Try to select another account
-			account, err = s.router.SelectNextAccount(account)
-			if err != nil {
-				log.Printf("No valid accounts available")
-				req.URL.Scheme = "http"
-				req.URL.Host = "localhost"
-				req.URL.Path = "/error"
-				return
-			}
-		}
-
-		// Get provider
-		provider := providers.GetProviderForAccount(account)
-		if provider == nil {
-			log.Printf("Provider not found: %s", account.Provider)
-			req.URL.Scheme = "http"
-			req.URL.Host = "localhost"
-			req.URL.Path = "/error"
-			return
-		}
-
-		// Get provider endpoint
-		target, err := url.Parse(provider.GetBaseURL())
-		if err != nil {
-			log.Printf("Error parsing provider URL: %v", err)
-			req.URL.Scheme = "http"
-			req.URL.Host = "localhost"
-			req.URL.Path = "/error"
-			return
-		}
-
-		// Authenticate request using provider BEFORE modifying URL
-		if err := provider.AuthenticateRequest(req, account); err != nil {
-			log.Printf("Error authenticating request: %v", err)
-			req.URL.Scheme = "http"
-			req.URL.Host = "localhost"
-			req.URL.Path = "/error"
-			return
-		}
-
-		// Only set URL after successful authentication
-		req.URL.Scheme = target.Scheme
-		req.URL.Host = target.Host
-
-		// Add account ID to context for quota tracking
-		req = req.WithContext(context.WithValue(req.Context(), "accountID", account.ID))
-	}
+	// Account selection, authentication and failover happen per attempt in
+	// failoverTransport, so the director has nothing to rewrite up front
+	director := func(req *http.Request) {}
 
 	// Modify response to track quota and rate limits
 	modifyResponse := func(resp *http.Response) error {
-		accountID, ok := resp.Request.Context().Value("accountID").(uint)
+		attempt, ok := resp.Request.Context().Value(attemptKey).(*upstreamAttempt)
 		if !ok {
 			return nil
 		}
 
-		// Determine success based on status code
-		statusCode := resp.StatusCode
-		success := statusCode >= 200 && statusCode < 300
-
-		// Get account to find provider
-		var account storage.Account
-		if err := s.db.First(&account, accountID).Error; err == nil {
-			provider := providers.GetProviderForAccount(&account)
-			if provider != nil {
-				// For non-streaming responses with JSON bodies, try to parse quota
-				// For streaming responses (SSE, etc.), tokens are counted from stream size
-				tokensUsed := int64(0)
-
-				// Only buffer body for non-streaming, non-empty responses
-				if resp.Header.Get("Content-Type") != "" &&
-					!isStreamingResponse(resp) &&
-					resp.ContentLength > 0 &&
-					resp.ContentLength < 1*1024*1024 { // Only buffer responses < 1MB
-
-					// Use TeeReader to buffer body without breaking streaming
-					bufferedBody, newBody, err := teeResponseBody(resp)
-					if err == nil && bufferedBody != nil {
-						// Parse quota from buffered body
-						tokensUsed, _ = provider.ParseQuotaFromBody(bufferedBody)
-						// Replace body with new reader
-						resp.Body = newBody
-					}
-				}
-
-				// Also try header-based quota parsing (fallback if body parsing didn't work)
-				if tokensUsed == 0 {
-					tokensUsed, _ = provider.ParseQuotaFromResponse(resp)
-				}
-
-				// Parse rate limit headers using provider-specific config
-				// Handle rate limits from headers
-				client, _ := providers.CreateProviderClient(&account)
-				if client != nil {
-					rateLimits, err := client.ParseRateLimitsFromResponse(resp)
-					if err == nil && rateLimits != nil {
-						// Update account with auto-detected limits
-						s.updateAccountRateLimits(accountID, rateLimits)
-
-						// Check if we need to enter cooldown based on remaining quota
-						if (rateLimits.TokensLimit > 0 && rateLimits.TokensRemaining == 0) ||
-							(rateLimits.RequestsLimit > 0 && rateLimits.RequestsRemaining == 0) {
-							log.Printf("Rate limit exhausted (headers) for account %d - entering cooldown", accountID)
-
-							// Use reset time if available, otherwise default 15 mins
-							cooldownDuration := 15 * time.Minute
-							if !rateLimits.TokensReset.IsZero() {
-								storage.SetAccountCooldown(accountID, rateLimits.TokensReset)
-							} else if !rateLimits.RequestsReset.IsZero() {
-								storage.SetAccountCooldown(accountID, rateLimits.RequestsReset)
-							} else {
-								storage.SetAccountCooldown(accountID, time.Now().Add(cooldownDuration))
-							}
-						}
-					}
-				}
-
-				// Record usage
-				s.quotaTracker.RecordUsage(accountID, tokensUsed, 1, statusCode, success)
-
-				// Handle auth failures - increment consecutive failures before disabling
-				if statusCode == 401 || statusCode == 403 {
-					s.handleAuthFailure(accountID, resp)
-				}
-
-				// Check for rate limit from status code (429)
-				if statusCode == 429 || provider.DetectRateLimit(resp) {
-					log.Printf("Rate limit detected (status 429) for account %d", accountID)
-
-					// If we didn't get a reset time from headers, set a default cooldown
-					var accountCheck storage.Account
-					if err := s.db.First(&accountCheck, accountID).Error; err == nil {
-						if accountCheck.Status != "cooldown" {
-							// 15 minute cooldown for 429 if no header info
-							storage.SetAccountCooldown(accountID, time.Now().Add(15*time.Minute))
-						}
-					}
-				}
-			}
+		if !attempt.Tracked {
+			s.trackResponse(resp, attempt)
+		}
+		if err := translateResponse(resp, attempt); err != nil {
+			return err
+		}
+		if attempt.Fallback {
+			resp.Header.Set(fallbackHeader, attempt.Model)
 		}
 
+		// A substitute model's answer must not be served to later requests for the original
+		if info, ok := resp.Request.Context().Value(requestInfoKey).(*requestInfo); ok && info.Cache != nil && !attempt.Fallback {
+			s.cacheResponse(resp, attempt, info.Cache)
+		}
 		return nil
 	}
 
 	s.proxy = &httputil.ReverseProxy{
 		Director:       director,
+		Transport:      &failoverTransport{server: s, base: http.DefaultTransport},
 		ModifyResponse: modifyResponse,
+		ErrorHandler:   s.handleProxyError,
 	}
 
-	// Create HTTP server
+	// Listen up front so a port that is already taken is reported to the caller
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.port, err)
+	}
+	s.server = s.newHTTPServer(s.port)
+	s.running = true
+	if s.stopFlush != nil {
+		close(s.stopFlush) // Left running when the previous server failed without a Stop
+	}
+	s.stopFlush = make(chan struct{})
+
+	go s.serve(s.server, listener, s.port)
+	go s.flushHealthLoop(s.stopFlush)
+
+	return nil
+}
+
+// newHTTPServer returns an HTTP server that routes everything to handleRequest
+func (s *Server) newHTTPServer(port int) *http.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleRequest)
 
-	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
 		Handler: mux,
 	}
+}
 
-	s.running = true
-
-	// Start server in goroutine
-	go func() {
-		log.Printf("Proxy server starting on port %d", s.port)
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Printf("Proxy server error: %v", err)
-			s.mu.Lock()
+// serve accepts connections on listener until server is shut down. Only the
+// current server failing marks the proxy as stopped; one that is draining after
+// a rebind doesn't.
+func (s *Server) serve(server *http.Server, listener net.Listener, port int) {
+	log.Printf("Proxy server starting on port %d", port)
+	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		log.Printf("Proxy server error: %v", err)
+		s.mu.Lock()
+		if s.server == server {
 			s.running = false
-			s.mu.Unlock()
 		}
-	}()
-
-	return nil
+		s.mu.Unlock()
+	}
 }
 
 func (s *Server) Stop() {
@@ -250,6 +157,9 @@ func (s *Server) Stop() {
 	if err := s.server.Shutdown(ctx); err != nil {
 		log.Printf("Error shutting down proxy server: %v", err)
 	}
+	close(s.stopFlush)
+	s.stopFlush = nil
+	s.flushHealth()
 
 	s.running = false
 	log.Println("Proxy server stopped")
@@ -263,27 +173,229 @@ func (s *Server) IsRunning() bool {
 
 func (s *Server) handleRequest(w http.ResponseWriter, r *http.Request) {
 	if s.proxy == nil {
-		http.Error(w, "Proxy not initialized", http.StatusInternalServerError)
+		writeProxyError(w, r, http.StatusInternalServerError, "Proxy not initialized")
 		return
 	}
 
-	// Enforce API key if configured
-	var proxyConfig storage.ProxyConfig
-	if err := s.db.First(&proxyConfig).Error; err == nil && proxyConfig.APIKey != "" {
-		auth := r.Header.Get("Authorization")
-		const prefix = "Bearer "
+	// One settings snapshot serves the whole request, so a concurrent settings
+	// change never mixes old keys with a new routing strategy or the reverse
+	config := s.loadProxyConfig()
+
+	// Enforce the shared API key or an issued client key if any are configured
+	clientKey, authErr := s.authenticateClient(r, config)
+	if authErr != nil {
+		writeProxyError(w, r, authErr.Status, authErr.Message)
+		return
+	}
 
-		if !strings.HasPrefix(auth, prefix) || strings.TrimPrefix(auth, prefix) != proxyConfig.APIKey {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	info, err := parseRequestInfo(r)
+	if err != nil {
+		writeProxyError(w, r, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	info.Config = config
+
+	// Aliases resolve first, so a key's model scope is checked against the models actually served
+	s.resolveModelAlias(info)
+	if clientKey != nil {
+		if authErr := s.authorizeClient(clientKey, info); authErr != nil {
+			writeProxyError(w, r, authErr.Status, authErr.Message)
 			return
 		}
+		info.ClientKey = clientKey
+		s.clientKeys.touch(clientKey.ID, time.Now())
+	}
+
+	if !s.checkContextWindow(w, info, config) {
+		return
 	}
 
-	s.proxy.ServeHTTP(w, r)
+	// The model list is answered locally from every account rather than by
+	// whichever single account the request would be routed to
+	if isModelListRequest(r, info) {
+		s.serveModels(w, r, info)
+		return
+	}
+
+	// Answer repeated deterministic requests from the response cache
+	if config.ResponseCache && !cacheBypassed(r.Header) {
+		if key := responseCacheKey(info); key != "" {
+			if entry, ok := s.cache.get(key); ok {
+				s.serveCached(w, info, entry)
+				return
+			}
+			info.Cache = newCacheLookup(key, config)
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), requestInfoKey, info)
+	s.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// handleProxyError reports selection and upstream failures to the client
+func (s *Server) handleProxyError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Proxy error for %s: %v", r.URL.Path, err)
+
+	var (
+		paced   *pacedError
+		cooling *cooldownError
+	)
+	switch {
+	case errors.As(err, &paced):
+		w.Header().Set("Retry-After", retryAfterSeconds(paced.Wait))
+		writeProxyError(w, r, http.StatusTooManyRequests, err.Error())
+	case errors.As(err, &cooling):
+		w.Header().Set("Retry-After", retryAfterSeconds(time.Until(cooling.Until)))
+		writeProxyError(w, r, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, ErrModelNotServed):
+		writeProxyError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrNoAccounts), errors.Is(err, ErrNoCompatibleAccount), errors.Is(err, ErrAccountsSaturated),
+		errors.Is(err, ErrCircuitOpen):
+		writeProxyError(w, r, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeProxyError(w, r, http.StatusBadGateway, err.Error())
+	}
+}
+
+// selectAccount picks an account for the request, skipping ones that aren't routable
+func (s *Server) selectAccount(req RouteRequest) (*storage.Account, error) {
+	account, err := s.router.SelectAccount(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pre-request validation: Check if account is valid for routing
+	if !s.isAccountValidForRouting(account, req.Strategy) {
+		log.Printf("Account %d not valid for routing (status: %s)", account.ID, account.Status)
+		// Try to select another account
+		account, err = s.router.SelectNextAccount(account, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return account, nil
 }
 
-// isAccountValidForRouting checks if account is valid for routing
-func (s *Server) isAccountValidForRouting(account *storage.Account) bool {
+// trackResponse records quota usage, rate limits and cooldowns for one upstream attempt
+func (s *Server) trackResponse(resp *http.Response, attempt *upstreamAttempt) {
+	// Determine success based on status code
+	statusCode := resp.StatusCode
+	success := statusCode >= 200 && statusCode < 300
+
+	// Get account to find provider
+	accountID := attempt.Account.ID
+	var account storage.Account
+	if err := s.db.First(&account, accountID).Error; err == nil {
+		provider := providers.GetProviderForAccount(&account)
+		if provider != nil {
+			// For non-streaming responses with JSON bodies, try to parse quota
+			// For SSE streams, usage is read from the events as they pass (see below)
+			tokensUsed := int64(0)
+
+			// Only buffer body for non-streaming, non-empty responses
+			if resp.Header.Get("Content-Type") != "" &&
+				!isStreamingResponse(resp) &&
+				resp.ContentLength > 0 &&
+				resp.ContentLength < 1*1024*1024 { // Only buffer responses < 1MB
+
+				// Use TeeReader to buffer body without breaking streaming
+				bufferedBody, newBody, err := teeResponseBody(resp)
+				if err == nil && bufferedBody != nil {
+					// Parse quota from buffered body
+					tokensUsed, _ = provider.ParseQuotaFromBody(bufferedBody)
+					// Replace body with new reader
+					resp.Body = newBody
+				}
+			}
+
+			// Also try header-based quota parsing (fallback if body parsing didn't work)
+			if tokensUsed == 0 {
+				tokensUsed, _ = provider.ParseQuotaFromResponse(resp)
+			}
+
+			// Parse rate limit headers using provider-specific config
+			// Handle rate limits from headers
+			client, _ := providers.CreateProviderClient(&account)
+			if client != nil {
+				rateLimits, err := client.ParseRateLimitsFromResponse(resp)
+				if err == nil && rateLimits != nil {
+					// Update account with auto-detected limits
+					s.updateAccountRateLimits(accountID, rateLimits)
+
+					// Check if we need to enter cooldown based on remaining quota
+					if (rateLimits.TokensLimit > 0 && rateLimits.TokensRemaining == 0) ||
+						(rateLimits.RequestsLimit > 0 && rateLimits.RequestsRemaining == 0) {
+						log.Printf("Rate limit exhausted (headers) for account %d - entering cooldown", accountID)
+
+						// Use reset time if available, otherwise default 15 mins
+						cooldownDuration := 15 * time.Minute
+						if !rateLimits.TokensReset.IsZero() {
+							storage.SetAccountCooldown(accountID, rateLimits.TokensReset)
+						} else if !rateLimits.RequestsReset.IsZero() {
+							storage.SetAccountCooldown(accountID, rateLimits.RequestsReset)
+						} else {
+							storage.SetAccountCooldown(accountID, time.Now().Add(cooldownDuration))
+						}
+					}
+				}
+			}
+
+			// Record usage (one history entry per upstream attempt). Streams report
+			// usage in their events, so those are recorded once the stream ends.
+			entry := storage.QuotaHistory{
+				AccountID:     accountID,
+				TokensUsed:    tokensUsed,
+				RequestsCount: 1,
+				Model:         attempt.Model,
+				StatusCode:    statusCode,
+				Success:       success,
+				Attempt:       attempt.Number,
+				ClientKeyID:   attempt.ClientKeyID,
+			}
+			if success && isEventStream(resp) && translator.CanMeterStream(attempt.UpstreamFormat) {
+				resp.Body = newMeteredStream(resp.Body, attempt.UpstreamFormat, func(usage translator.StreamUsage) {
+					if total := usage.Total(); total > 0 {
+						entry.TokensUsed = total
+					}
+					s.keyUsage.add(entry.ClientKeyID, entry.TokensUsed, time.Now())
+					s.quotaTracker.Record(entry)
+				})
+			} else {
+				s.keyUsage.add(entry.ClientKeyID, entry.TokensUsed, time.Now())
+				s.quotaTracker.Record(entry)
+			}
+
+			// Handle auth failures - increment consecutive failures before disabling
+			if statusCode == 401 || statusCode == 403 {
+				s.handleAuthFailure(accountID, resp)
+			}
+
+			// Check for rate limit from status code (429)
+			if statusCode == 429 || provider.DetectRateLimit(resp) {
+				log.Printf("Rate limit detected (status 429) for account %d", accountID)
+
+				// If we didn't get a reset time from headers, set a default cooldown
+				var accountCheck storage.Account
+				if err := s.db.First(&accountCheck, accountID).Error; err == nil {
+					if accountCheck.Status != "cooldown" {
+						// 15 minute cooldown for 429 if no header info
+						storage.SetAccountCooldown(accountID, time.Now().Add(15*time.Minute))
+					}
+				}
+			}
+		}
+	}
+}
+
+// isAccountValidForRouting checks if account is valid for routing under the
+// given strategy, empty for the router's default
+func (s *Server) isAccountValidForRouting(account *storage.Account, strategy string) bool {
+	if strategy == "" {
+		strategy = s.router.Strategy()
+	}
+
 	// Skip disabled accounts
 	if account.Status == "disabled" {
 		return false
@@ -300,7 +412,7 @@ func (s *Server) isAccountValidForRouting(account *storage.Account) bool {
 	}
 
 	// Skip rate-limited accounts in fill_first mode
-	if account.Status == "rate_limited" && s.routingStrategy == "fill_first" {
+	if account.Status == "rate_limited" && strategy == StrategyFillFirst {
 		return false
 	}
 
@@ -325,6 +437,8 @@ func (s *Server) updateAccountRateLimits(accountID uint, limits *providers.RateL
 		}
 	}
 
+	s.router.pacer.observe(accountID, limits, time.Now())
+
 	// Update rate limit remaining values
 	account.RateLimitRequests = limits.RequestsLimit
 	account.RateLimitRequestsRemaining = limits.RequestsRemaining
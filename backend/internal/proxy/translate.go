@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"quotio-electron-go/backend/internal/providers"
+	"quotio-electron-go/backend/internal/translator"
+	"strconv"
+	"strings"
+)
+
+// upstreamChatPath returns the generation endpoint for an API format
+func upstreamChatPath(format string, model string, stream bool) string {
+	switch format {
+	case providers.APIFormatAnthropic:
+		return "/v1/messages"
+	case providers.APIFormatGemini:
+		if stream {
+			return "/v1beta/models/" + model + ":streamGenerateContent"
+		}
+		return "/v1beta/models/" + model + ":generateContent"
+	default:
+		return "/v1/chat/completions"
+	}
+}
+
+// translateResponse converts an upstream response into the client's API format.
+// Successful streams are converted event by event; other bodies are buffered.
+func translateResponse(resp *http.Response, attempt *upstreamAttempt) error {
+	from, to := attempt.UpstreamFormat, attempt.ClientFormat
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if attempt.HideUsage && success && isEventStream(resp) {
+		resp.Body = translator.DropUsageChunks(resp.Body)
+	}
+
+	if from == to || resp.Body == nil {
+		return nil
+	}
+
+	if success && isEventStream(resp) {
+		resp.Body = translator.TranslateStream(from, to, resp.Body, attempt.ClientModel)
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if success {
+		if translated, err := translator.TranslateResponse(from, to, body, attempt.ClientModel); err == nil {
+			body = translated
+		}
+	} else {
+		body = translator.TranslateError(from, to, resp.StatusCode, body)
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	resp.Header.Set("Content-Type", "application/json")
+	return nil
+}
+
+// isEventStream reports whether the response is a server-sent event stream
+func isEventStream(resp *http.Response) bool {
+	return strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream")
+}
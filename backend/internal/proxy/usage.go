@@ -0,0 +1,65 @@
+package proxy
+
+import (
+	"io"
+	"quotio-electron-go/backend/internal/translator"
+	"sync"
+)
+
+// meteredStream relays an upstream SSE body to the client while reading the
+// token usage reported in its events. onDone runs once the stream has ended
+// or the client has gone away.
+type meteredStream struct {
+	body   io.ReadCloser
+	tee    io.Reader
+	events *io.PipeWriter
+	parsed chan struct{}
+	once   sync.Once
+	usage  translator.StreamUsage
+	onDone func(usage translator.StreamUsage)
+}
+
+func newMeteredStream(body io.ReadCloser, format string, onDone func(usage translator.StreamUsage)) io.ReadCloser {
+	pr, pw := io.Pipe()
+	m := &meteredStream{
+		body:   body,
+		tee:    io.TeeReader(body, pw),
+		events: pw,
+		parsed: make(chan struct{}),
+		onDone: onDone,
+	}
+
+	go func() {
+		defer close(m.parsed)
+		translator.ReadEvents(pr, func(ev translator.Event) error {
+			m.usage.Observe(format, ev)
+			return nil
+		})
+		// Keep draining so the relay never blocks if parsing stopped early
+		io.Copy(io.Discard, pr)
+	}()
+
+	return m
+}
+
+func (m *meteredStream) Read(p []byte) (int, error) {
+	n, err := m.tee.Read(p)
+	if err != nil {
+		m.finish()
+	}
+	return n, err
+}
+
+func (m *meteredStream) Close() error {
+	err := m.body.Close()
+	m.finish()
+	return err
+}
+
+func (m *meteredStream) finish() {
+	m.once.Do(func() {
+		m.events.Close()
+		<-m.parsed
+		m.onDone(m.usage)
+	})
+}
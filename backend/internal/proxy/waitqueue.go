@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log"
+	"quotio-electron-go/backend/internal/storage"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWaitQueueTimeout is used when ProxyConfig leaves WaitQueueTimeout unset
+	defaultWaitQueueTimeout = 5 * time.Minute
+	// queuePollInterval is how often a queued request checks for a free
+	// concurrency slot once its account is back but busy
+	queuePollInterval = 500 * time.Millisecond
+)
+
+// queuedRequest is one request held in the wait queue
+type queuedRequest struct {
+	readyAt time.Time     // When the account it waits for is expected back
+	wake    chan struct{} // Signalled when a request ahead of it stops trying
+}
+
+// waitQueue holds requests that found every eligible account cooling down or
+// rate limited until an account comes back. It is first in, first out: a
+// request only gets to try for an account once no request queued ahead of it
+// is due as well.
+type waitQueue struct {
+	mu      sync.Mutex
+	waiting *list.List // Front is the longest waiting request
+}
+
+func newWaitQueue() *waitQueue {
+	return &waitQueue{waiting: list.New()}
+}
+
+// join queues a request at the back
+func (q *waitQueue) join() *list.Element {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiting.PushBack(&queuedRequest{wake: make(chan struct{}, 1)})
+}
+
+// leave takes a request out of the queue and lets the ones behind it check for their turn
+func (q *waitQueue) leave(elem *list.Element) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.waiting.Remove(elem)
+	q.wakeAll()
+}
+
+// schedule records when the request is next due to try, which also lets the
+// ones behind it take their turn in the meantime
+func (q *waitQueue) schedule(elem *list.Element, readyAt time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	elem.Value.(*queuedRequest).readyAt = readyAt
+	q.wakeAll()
+}
+
+// hasTurn reports whether the request may try now: no request ahead of it is due
+func (q *waitQueue) hasTurn(elem *list.Element, now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for e := q.waiting.Front(); e != elem; e = e.Next() {
+		if !e.Value.(*queuedRequest).readyAt.After(now) {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *waitQueue) wakeAll() {
+	for e := q.waiting.Front(); e != nil; e = e.Next() {
+		select {
+		case e.Value.(*queuedRequest).wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// depth returns how many requests are waiting
+func (q *waitQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiting.Len()
+}
+
+// QueueDepth returns how many requests are waiting for an account to come back
+func (s *Server) QueueDepth() int {
+	return s.queue.depth()
+}
+
+// waitQueueTimeout returns the longest a request may wait in the queue
+func waitQueueTimeout(config storage.ProxyConfig) time.Duration {
+	if config.WaitQueueTimeout <= 0 {
+		return defaultWaitQueueTimeout
+	}
+	return time.Duration(config.WaitQueueTimeout) * time.Second
+}
+
+// retryTime returns when an account is expected back after a selection failure,
+// or false if the failure isn't one that waiting fixes
+func retryTime(err error, now time.Time) (time.Time, bool) {
+	var (
+		paced   *pacedError
+		cooling *cooldownError
+	)
+	switch {
+	case errors.As(err, &cooling):
+		return cooling.Until, true
+	case errors.As(err, &paced):
+		return now.Add(paced.Wait), true
+	}
+	return time.Time{}, false
+}
+
+// isWaitable reports whether a selection failure is one the wait queue can sit out
+func isWaitable(err error) bool {
+	_, ok := retryTime(err, time.Now())
+	return ok
+}
+
+// waitForAccount holds the request in the wait queue until the earliest
+// cooldown or rate-limit reset has passed, then acquires an account for it.
+// It gives up with the selection error once the next account isn't expected
+// back before deadline. A request that hasn't tried yet passes a nil err: it
+// only waits for the requests queued ahead of it to take their turn, and any
+// selection failure is returned for the caller to handle as usual.
+func (s *Server) waitForAccount(ctx context.Context, route *RouteRequest, err error, deadline time.Time) (*storage.Account, error) {
+	elem := s.queue.join()
+	defer s.queue.leave(elem)
+	waiter := elem.Value.(*queuedRequest)
+	turnOnly := err == nil
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for queued := false; ; queued = true {
+		now := time.Now()
+		retryAt, ok := now, true
+		if err != nil {
+			retryAt, ok = retryTime(err, now)
+			if !ok && errors.Is(err, ErrAccountsSaturated) {
+				// The account is back but busy with requests queued ahead of this one
+				retryAt, ok = now.Add(queuePollInterval), true
+			}
+		}
+		if !ok || retryAt.After(deadline) {
+			return nil, err
+		}
+		if !queued && !turnOnly {
+			log.Printf("No account available for %s, waiting %s in queue (%d waiting)",
+				route.Model, time.Until(retryAt).Round(time.Second), s.queue.depth())
+		}
+		s.queue.schedule(elem, retryAt)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(retryAt))
+		for due := false; !due || !s.queue.hasTurn(elem, time.Now()); {
+			select {
+			case <-timer.C:
+				due = true
+			case <-waiter.wake:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		var account *storage.Account
+		account, err = s.acquireAccount(ctx, route, nil)
+		if err == nil || turnOnly {
+			return account, err
+		}
+	}
+}
@@ -28,22 +28,33 @@ func NewTracker(db *gorm.DB) *Tracker {
 }
 
 func (t *Tracker) RecordUsage(accountID uint, tokensUsed int64, requestsCount int, statusCode int, success bool) {
+	t.Record(storage.QuotaHistory{
+		AccountID:     accountID,
+		TokensUsed:    tokensUsed,
+		RequestsCount: requestsCount,
+		StatusCode:    statusCode,
+		Success:       success,
+	})
+}
+
+// Record updates counters and persists a history entry with model and attempt details
+func (t *Tracker) Record(entry storage.QuotaHistory) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	// Update in-memory counter
-	if t.counters[accountID] == nil {
-		t.counters[accountID] = &AccountCounter{}
+	if t.counters[entry.AccountID] == nil {
+		t.counters[entry.AccountID] = &AccountCounter{}
 	}
-	counter := t.counters[accountID]
-	counter.RequestsCount += int64(requestsCount)
-	counter.TokensUsed += tokensUsed
+	counter := t.counters[entry.AccountID]
+	counter.RequestsCount += int64(entry.RequestsCount)
+	counter.TokensUsed += entry.TokensUsed
 	counter.LastRequest = time.Now()
 
 	// Update database (async to avoid blocking)
 	go func() {
-		storage.UpdateQuotaUsage(accountID, tokensUsed, requestsCount)
-		storage.RecordQuotaHistory(accountID, tokensUsed, requestsCount, statusCode, success)
+		storage.UpdateQuotaUsage(entry.AccountID, entry.TokensUsed, entry.RequestsCount)
+		storage.RecordQuotaHistoryEntry(entry)
 	}()
 }
 
@@ -84,4 +95,3 @@ func (t *Tracker) ResetAccount(accountID uint) error {
 	delete(t.counters, accountID)
 	return storage.ResetQuota(accountID)
 }
-
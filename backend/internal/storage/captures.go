@@ -0,0 +1,15 @@
+package storage
+
+// SaveCapture stores a capture and prunes all but the newest keep captures
+func SaveCapture(capture *Capture, keep int) error {
+	if err := DB.Create(capture).Error; err != nil {
+		return err
+	}
+	if keep <= 0 {
+		return nil
+	}
+	return DB.Exec(
+		"DELETE FROM captures WHERE id <= (SELECT id FROM captures ORDER BY id DESC LIMIT 1 OFFSET ?)",
+		keep,
+	).Error
+}
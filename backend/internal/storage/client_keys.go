@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// clientKeyPrefix marks keys issued by the proxy
+const clientKeyPrefix = "qk-"
+
+// GenerateClientKey returns a new random client key
+func GenerateClientKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return clientKeyPrefix + hex.EncodeToString(buf), nil
+}
+
+// HashClientKey returns the stored form of a client key
+func HashClientKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClientKeyDisplayPrefix returns the part of a key that is safe to show in listings
+func ClientKeyDisplayPrefix(key string) string {
+	const visible = 8
+	if len(key) <= len(clientKeyPrefix)+visible {
+		return key
+	}
+	return key[:len(clientKeyPrefix)+visible]
+}
+
+// GetClientKeys returns every client key that has been issued, including revoked ones
+func GetClientKeys() ([]ClientKey, error) {
+	var keys []ClientKey
+	err := DB.Find(&keys).Error
+	return keys, err
+}
+
+// TouchClientKey records that a client key was just used
+func TouchClientKey(keyID uint) error {
+	return DB.Model(&ClientKey{}).Where("id = ?", keyID).UpdateColumn("last_used_at", time.Now()).Error
+}
+
+// GetClientKeyTokensSince sums the tokens used by a client key since the given time
+func GetClientKeyTokensSince(keyID uint, since time.Time) (int64, error) {
+	var total int64
+	err := DB.Model(&QuotaHistory{}).
+		Where("client_key_id = ? AND timestamp >= ?", keyID, since).
+		Select("COALESCE(SUM(tokens_used), 0)").
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,20 @@
+package storage
+
+// FindFallbackChain returns the fallback chain configured for a model, or nil if there is none
+func FindFallbackChain(model string) (*FallbackChain, error) {
+	var chains []FallbackChain
+	if err := DB.Where("model = ?", model).Limit(1).Find(&chains).Error; err != nil {
+		return nil, err
+	}
+	if len(chains) == 0 {
+		return nil, nil
+	}
+	return &chains[0], nil
+}
+
+// GetFallbackChains returns every configured fallback chain
+func GetFallbackChains() ([]FallbackChain, error) {
+	var chains []FallbackChain
+	err := DB.Find(&chains).Error
+	return chains, err
+}
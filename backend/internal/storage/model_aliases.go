@@ -0,0 +1,20 @@
+package storage
+
+// FindModelAlias returns the alias with the given name, or nil if there is none
+func FindModelAlias(name string) (*ModelAlias, error) {
+	var aliases []ModelAlias
+	if err := DB.Where("name = ?", name).Limit(1).Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+	if len(aliases) == 0 {
+		return nil, nil
+	}
+	return &aliases[0], nil
+}
+
+// GetModelAliases returns every model alias
+func GetModelAliases() ([]ModelAlias, error) {
+	var aliases []ModelAlias
+	err := DB.Find(&aliases).Error
+	return aliases, err
+}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"encoding/json"
 	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -40,6 +43,8 @@ type Account struct {
 	SupportsManualAuth bool      `gorm:"default:true" json:"supports_manual_auth"` // Can add manually
 	ModelAccess        string    `gorm:"type:text" json:"model_access"`            // JSON array of models
 	Priority           int       `gorm:"default:0" json:"priority"`                // For routing
+	Weight             int       `gorm:"default:1" json:"weight"`                  // Traffic share under the weighted strategy
+	MaxConcurrent      int       `gorm:"default:0" json:"max_concurrent"`          // Parallel requests allowed, 0 for unlimited
 	LastUsed           time.Time `json:"last_used"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
@@ -113,6 +118,33 @@ func (a *Account) AfterFind(tx *gorm.DB) error {
 	return nil
 }
 
+// AllowedModels returns the models listed in ModelAccess.
+// An empty result means the account is not restricted to specific models.
+func (a *Account) AllowedModels() []string {
+	return parseList(a.ModelAccess)
+}
+
+// parseList decodes a JSON array of strings stored in a text column
+func parseList(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	var items []string
+	if err := json.Unmarshal([]byte(value), &items); err == nil {
+		return items
+	}
+
+	// Tolerate plain comma-separated lists entered by hand
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // QuotaHistory tracks historical quota usage
 type QuotaHistory struct {
 	ID            uint      `gorm:"primarykey" json:"id"`
@@ -123,18 +155,85 @@ type QuotaHistory struct {
 	Model         string    `json:"model"` // Model used (e.g., "claude-3-opus")
 	StatusCode    int       `json:"status_code"`
 	Success       bool      `json:"success"`
+	Attempt       int       `gorm:"default:1" json:"attempt"`    // Failover attempt number within a proxied request
+	ClientKeyID   uint      `gorm:"index" json:"client_key_id"`  // Client key that made the request, 0 for the shared key
+	Cached        bool      `gorm:"default:false" json:"cached"` // Served from the response cache without an upstream call
 	Timestamp     time.Time `gorm:"index" json:"timestamp"`
 }
 
+// ClientKey is an API key issued to a proxy client. Only a hash of the key is
+// stored; the plaintext is shown once when the key is created or rotated.
+type ClientKey struct {
+	ID                 uint       `gorm:"primarykey" json:"id"`
+	Name               string     `gorm:"not null" json:"name"`
+	KeyHash            string     `gorm:"not null;uniqueIndex" json:"-"`
+	KeyPrefix          string     `json:"key_prefix"`                         // Leading characters, to tell keys apart
+	AllowedProviders   string     `gorm:"type:text" json:"allowed_providers"` // JSON array, empty for all
+	AllowedModels      string     `gorm:"type:text" json:"allowed_models"`    // JSON array, empty for all
+	RPM                int        `gorm:"default:0" json:"rpm"`               // Requests per minute, 0 for unlimited
+	DailyTokenBudget   int64      `gorm:"default:0" json:"daily_token_budget"`
+	MonthlyTokenBudget int64      `gorm:"default:0" json:"monthly_token_budget"`
+	Revoked            bool       `gorm:"default:false" json:"revoked"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// ProviderScope returns the providers the key may use, empty for all
+func (k *ClientKey) ProviderScope() []string {
+	return parseList(k.AllowedProviders)
+}
+
+// ModelScope returns the models the key may request, empty for all
+func (k *ClientKey) ModelScope() []string {
+	return parseList(k.AllowedModels)
+}
+
+// ModelAlias is a user-defined model name, such as "fast" or "smart", that
+// resolves to provider-specific model IDs. Whichever account serves the request
+// gets the first target it offers.
+type ModelAlias struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Name        string    `gorm:"not null;uniqueIndex" json:"name"`
+	Models      string    `gorm:"type:text;not null" json:"models"` // JSON array of model IDs, in order of preference
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Targets returns the model IDs the alias resolves to, in order of preference
+func (a *ModelAlias) Targets() []string {
+	return parseList(a.Models)
+}
+
+// FallbackChain lists the models that stand in for a model, in order, once no
+// account can serve it
+type FallbackChain struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Model     string    `gorm:"not null;uniqueIndex" json:"model"`
+	Fallbacks string    `gorm:"type:text;not null" json:"fallbacks"` // JSON array of model IDs, tried in order
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Models returns the fallback model IDs in the order they are tried
+func (c *FallbackChain) Models() []string {
+	return parseList(c.Fallbacks)
+}
+
 // ProviderHealth tracks health status of provider accounts
 type ProviderHealth struct {
 	ID                  uint      `gorm:"primarykey" json:"id"`
 	AccountID           uint      `gorm:"not null;uniqueIndex" json:"account_id"`
 	Account             Account   `gorm:"foreignKey:AccountID" json:"account,omitempty"`
 	IsHealthy           bool      `gorm:"default:true" json:"is_healthy"`
-	ResponseTime        int64     `json:"response_time_ms"` // Response time in milliseconds
+	ResponseTime        int64     `json:"response_time_ms"` // Moving average time to first byte in milliseconds
 	LastChecked         time.Time `json:"last_checked"`
 	ConsecutiveFailures int       `gorm:"default:0" json:"consecutive_failures"`
+	CircuitState        string    `gorm:"default:closed" json:"circuit_state"` // closed, open, half_open
+	CircuitFailures     int       `gorm:"default:0" json:"circuit_failures"`   // Consecutive server errors or dial failures
+	CircuitRetryAt      time.Time `json:"circuit_retry_at"`                    // When an open circuit lets a probe through
 	CreatedAt           time.Time `json:"created_at"`
 	UpdatedAt           time.Time `json:"updated_at"`
 }
@@ -143,9 +242,70 @@ type ProviderHealth struct {
 type ProxyConfig struct {
 	ID              uint   `gorm:"primarykey" json:"id"`
 	Port            int    `gorm:"default:8081" json:"port"`
-	RoutingStrategy string `gorm:"default:round_robin" json:"routing_strategy"` // round_robin, fill_first
+	RoutingStrategy string `gorm:"default:round_robin" json:"routing_strategy"` // round_robin, fill_first, priority, weighted, adaptive, most_remaining
 	AutoStart       bool   `gorm:"default:false" json:"auto_start"`
-	APIKey          string `gorm:"type:text" json:"api_key"` // API key for proxy authentication
+	APIKey          string `gorm:"type:text" json:"api_key"`      // API key for proxy authentication
+	MaxAttempts     int    `gorm:"default:3" json:"max_attempts"` // Upstream attempts per request before giving up
+
+	// CrossProviderFallback lets other providers' accounts serve a request with their
+	// default model while every account for the requested model is cooling down
+	CrossProviderFallback bool `gorm:"default:false" json:"cross_provider_fallback"`
+
+	// TranslateResponses lets Responses API requests reach accounts that only speak
+	// Chat Completions (or another format) by translating them
+	TranslateResponses bool `gorm:"default:true" json:"translate_responses"`
+
+	// SessionAffinity keeps each conversation on the account that served its earlier
+	// turns (for prompt cache reuse) until that account becomes unavailable.
+	// SessionTTL is how long an idle conversation stays pinned, in seconds.
+	SessionAffinity bool `gorm:"default:true" json:"session_affinity"`
+	SessionTTL      int  `gorm:"default:1800" json:"session_ttl"`
+
+	// CaptureEnabled records every upstream exchange, with credentials redacted, for
+	// debugging. CaptureLimit is how many of the most recent captures are kept.
+	CaptureEnabled bool `gorm:"default:false" json:"capture_enabled"`
+	CaptureLimit   int  `gorm:"default:500" json:"capture_limit"`
+
+	// ResponseCache serves repeated non-streaming requests with temperature 0 from
+	// memory. Entries live for ResponseCacheTTL seconds within ResponseCacheMB of bodies.
+	ResponseCache    bool `gorm:"default:false" json:"response_cache"`
+	ResponseCacheTTL int  `gorm:"default:300" json:"response_cache_ttl"`
+	ResponseCacheMB  int  `gorm:"default:64" json:"response_cache_mb"`
+
+	// ContextReroute sends requests that are too long for the requested model's
+	// context window to a larger-context model instead of rejecting them
+	ContextReroute bool `gorm:"default:false" json:"context_reroute"`
+
+	// WaitQueue holds requests that find every eligible account cooling down or
+	// rate limited until one comes back, instead of failing them. A request waits
+	// at most WaitQueueTimeout seconds.
+	WaitQueue        bool `gorm:"default:false" json:"wait_queue"`
+	WaitQueueTimeout int  `gorm:"default:300" json:"wait_queue_timeout"`
+}
+
+// Capture is one recorded upstream exchange of a proxied request. Credentials in
+// headers and query parameters are redacted, and bodies are truncated to a cap.
+type Capture struct {
+	ID                uint        `gorm:"primarykey" json:"id"`
+	AccountID         uint        `gorm:"index" json:"account_id"`
+	Provider          string      `json:"provider"`
+	ClientKeyID       uint        `json:"client_key_id"`
+	Model             string      `json:"model"`
+	Format            string      `json:"format"` // API format sent upstream
+	Attempt           int         `json:"attempt"`
+	Method            string      `json:"method"`
+	Path              string      `json:"path"` // Request path before the provider's path mapping
+	UpstreamURL       string      `gorm:"type:text" json:"upstream_url"`
+	RequestHeaders    http.Header `gorm:"serializer:json" json:"request_headers,omitempty"`
+	RequestBody       string      `gorm:"type:text" json:"request_body,omitempty"`
+	RequestTruncated  bool        `json:"request_truncated"`
+	StatusCode        int         `json:"status_code"`
+	ResponseHeaders   http.Header `gorm:"serializer:json" json:"response_headers,omitempty"`
+	ResponseBody      string      `gorm:"type:text" json:"response_body,omitempty"`
+	ResponseTruncated bool        `json:"response_truncated"`
+	Error             string      `gorm:"type:text" json:"error,omitempty"`
+	DurationMs        int64       `json:"duration_ms"`
+	CreatedAt         time.Time   `gorm:"index" json:"created_at"`
 }
 
 // AgentConfig stores agent configuration
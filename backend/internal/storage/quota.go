@@ -44,13 +44,22 @@ func UpdateQuotaUsage(accountID uint, tokensUsed int64, requestsCount int) error
 
 // RecordQuotaHistory records quota usage in history
 func RecordQuotaHistory(accountID uint, tokensUsed int64, requestsCount int, statusCode int, success bool) error {
-	history := QuotaHistory{
+	return RecordQuotaHistoryEntry(QuotaHistory{
 		AccountID:     accountID,
 		TokensUsed:    tokensUsed,
 		RequestsCount: requestsCount,
 		StatusCode:    statusCode,
 		Success:       success,
-		Timestamp:     time.Now(),
+	})
+}
+
+// RecordQuotaHistoryEntry records a fully populated history entry
+func RecordQuotaHistoryEntry(history QuotaHistory) error {
+	if history.Timestamp.IsZero() {
+		history.Timestamp = time.Now()
+	}
+	if history.Attempt == 0 {
+		history.Attempt = 1
 	}
 	return DB.Create(&history).Error
 }
@@ -34,6 +34,10 @@ func Initialize(dbPath string) (*gorm.DB, error) {
 		&ProxyConfig{},
 		&AgentConfig{},
 		&ProviderHealth{},
+		&ClientKey{},
+		&Capture{},
+		&ModelAlias{},
+		&FallbackChain{},
 	)
 
 	if err != nil {
@@ -45,9 +49,16 @@ func Initialize(dbPath string) (*gorm.DB, error) {
 	if err := DB.First(&proxyConfig).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			defaultConfig := ProxyConfig{
-				Port:            8081,
-				RoutingStrategy: "round_robin",
-				AutoStart:       false,
+				Port:                  8081,
+				RoutingStrategy:       "round_robin",
+				AutoStart:             false,
+				CrossProviderFallback: false,
+				TranslateResponses:    true,
+				SessionAffinity:       true,
+				SessionTTL:            1800,
+				CaptureLimit:          500,
+				ResponseCacheTTL:      300,
+				ResponseCacheMB:       64,
 			}
 			DB.Create(&defaultConfig)
 		}
@@ -0,0 +1,199 @@
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Anthropic Messages wire types
+
+type claudeRequest struct {
+	Model         string            `json:"model"`
+	System        json.RawMessage   `json:"system,omitempty"` // string or []claudeContentBlock
+	Messages      []claudeMessage   `json:"messages"`
+	MaxTokens     int               `json:"max_tokens"`
+	Temperature   *float64          `json:"temperature,omitempty"`
+	TopP          *float64          `json:"top_p,omitempty"`
+	TopK          *int              `json:"top_k,omitempty"`
+	StopSequences []string          `json:"stop_sequences,omitempty"`
+	Stream        bool              `json:"stream,omitempty"`
+	Tools         []claudeTool      `json:"tools,omitempty"`
+	ToolChoice    *claudeToolChoice `json:"tool_choice,omitempty"`
+	Metadata      *claudeMetadata   `json:"metadata,omitempty"`
+}
+
+type claudeMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"` // string or []claudeContentBlock
+}
+
+type claudeContentBlock struct {
+	Type      string             `json:"type"`
+	Text      string             `json:"text,omitempty"`
+	Source    *claudeImageSource `json:"source,omitempty"`
+	ID        string             `json:"id,omitempty"`
+	Name      string             `json:"name,omitempty"`
+	Input     json.RawMessage    `json:"input,omitempty"`
+	ToolUseID string             `json:"tool_use_id,omitempty"`
+	Content   json.RawMessage    `json:"content,omitempty"` // tool_result: string or []claudeContentBlock
+	IsError   bool               `json:"is_error,omitempty"`
+	Thinking  string             `json:"thinking,omitempty"`
+}
+
+type claudeImageSource struct {
+	Type      string `json:"type"` // base64 or url
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type claudeTool struct {
+	Type        string          `json:"type,omitempty"` // Empty or "custom" for client tools; server tools are versioned types
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type claudeToolChoice struct {
+	Type                   string `json:"type"` // auto, any, tool, none
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type claudeMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+type claudeResponse struct {
+	ID           string               `json:"id"`
+	Type         string               `json:"type"`
+	Role         string               `json:"role"`
+	Model        string               `json:"model"`
+	Content      []claudeContentBlock `json:"content"`
+	StopReason   *string              `json:"stop_reason"`
+	StopSequence *string              `json:"stop_sequence"`
+	Usage        claudeUsage          `json:"usage"`
+}
+
+type claudeUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens,omitempty"`
+}
+
+type claudeStreamEvent struct {
+	Type         string              `json:"type"`
+	Message      *claudeResponse     `json:"message,omitempty"`
+	Index        int                 `json:"index"`
+	ContentBlock *claudeContentBlock `json:"content_block,omitempty"`
+	Delta        *claudeDelta        `json:"delta,omitempty"`
+	Usage        *claudeUsage        `json:"usage,omitempty"`
+	Error        *claudeError        `json:"error,omitempty"`
+}
+
+type claudeDelta struct {
+	Type         string  `json:"type,omitempty"`
+	Text         string  `json:"text,omitempty"`
+	PartialJSON  string  `json:"partial_json,omitempty"`
+	Thinking     string  `json:"thinking,omitempty"`
+	StopReason   string  `json:"stop_reason,omitempty"`
+	StopSequence *string `json:"stop_sequence,omitempty"`
+}
+
+type claudeErrorResponse struct {
+	Type  string      `json:"type"`
+	Error claudeError `json:"error"`
+}
+
+type claudeError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// parseClaudeContent normalizes content into blocks (plain strings become one text block)
+func parseClaudeContent(raw json.RawMessage) []claudeContentBlock {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if text == "" {
+			return nil
+		}
+		return []claudeContentBlock{{Type: "text", Text: text}}
+	}
+
+	var blocks []claudeContentBlock
+	json.Unmarshal(raw, &blocks)
+	return blocks
+}
+
+// claudeContentText concatenates the text blocks of content
+func claudeContentText(raw json.RawMessage) string {
+	var parts []string
+	for _, block := range parseClaudeContent(raw) {
+		if block.Type == "text" && block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// claudeToOpenAIFinishReason maps Anthropic stop reasons to OpenAI finish reasons
+func claudeToOpenAIFinishReason(reason string) string {
+	switch reason {
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		return "tool_calls"
+	case "refusal":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// openAIToClaudeStopReason maps OpenAI finish reasons to Anthropic stop reasons
+func openAIToClaudeStopReason(reason string) string {
+	switch reason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "refusal"
+	default:
+		return "end_turn"
+	}
+}
+
+// claudeStreamUsage reads input tokens from message_start and the running
+// output count from message_delta
+func claudeStreamUsage(ev Event, usage *StreamUsage) {
+	var event claudeStreamEvent
+	if err := json.Unmarshal(ev.Data, &event); err != nil {
+		return
+	}
+
+	var reported *claudeUsage
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			reported = &event.Message.Usage
+		}
+	case "message_delta":
+		reported = event.Usage
+	}
+	if reported == nil {
+		return
+	}
+
+	if input := reported.InputTokens + reported.CacheCreationInputTokens + reported.CacheReadInputTokens; input > 0 {
+		usage.InputTokens = input
+	}
+	if reported.OutputTokens > 0 {
+		usage.OutputTokens = reported.OutputTokens
+	}
+}
@@ -0,0 +1,418 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Anthropic Messages clients served by OpenAI Chat Completions upstreams
+
+func init() {
+	register(FormatAnthropic, FormatOpenAI, claudeToOpenAIRequest, openAIToClaudeResponse, newOpenAIToClaudeStream)
+}
+
+// claudeToOpenAIRequest converts a Messages request into a Chat Completions request
+func claudeToOpenAIRequest(body []byte, model string, stream bool) ([]byte, error) {
+	var req claudeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid messages request: %w", err)
+	}
+
+	out := openAIChatRequest{
+		Model:       model,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stream:      stream,
+	}
+	if req.MaxTokens > 0 {
+		maxTokens := req.MaxTokens
+		out.MaxTokens = &maxTokens
+	}
+	if len(req.StopSequences) > 0 {
+		out.Stop, _ = json.Marshal(req.StopSequences)
+	}
+	if stream {
+		// Ask for a final usage chunk so token accounting and message_delta usage work
+		out.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	if req.Metadata != nil {
+		out.User = req.Metadata.UserID
+	}
+
+	if system := claudeContentText(req.System); system != "" {
+		out.Messages = append(out.Messages, openAIMessage{Role: "system", Content: jsonString(system)})
+	}
+
+	for _, msg := range req.Messages {
+		blocks := parseClaudeContent(msg.Content)
+		if msg.Role == "assistant" {
+			out.Messages = append(out.Messages, claudeAssistantToOpenAI(blocks))
+			continue
+		}
+
+		// Tool results become separate tool messages ahead of the rest of the user turn
+		var parts []openAIContentPart
+		for _, block := range blocks {
+			switch block.Type {
+			case "tool_result":
+				result := claudeContentText(block.Content)
+				if block.IsError && result != "" {
+					result = "Error: " + result
+				}
+				out.Messages = append(out.Messages, openAIMessage{
+					Role:       "tool",
+					ToolCallID: block.ToolUseID,
+					Content:    jsonString(result),
+				})
+			case "text":
+				parts = append(parts, openAIContentPart{Type: "text", Text: block.Text})
+			case "image":
+				if url := claudeSourceToImageURL(block.Source); url != "" {
+					parts = append(parts, openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: url}})
+				}
+			}
+		}
+		if len(parts) > 0 {
+			out.Messages = append(out.Messages, openAIMessage{Role: "user", Content: openAIUserContent(parts)})
+		}
+	}
+
+	for _, tool := range req.Tools {
+		// Server tools (web search, computer use, ...) have no OpenAI equivalent
+		if tool.Type != "" && tool.Type != "custom" {
+			continue
+		}
+		out.Tools = append(out.Tools, openAITool{
+			Type: "function",
+			Function: openAIFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.InputSchema,
+			},
+		})
+	}
+
+	if req.ToolChoice != nil && len(out.Tools) > 0 {
+		switch req.ToolChoice.Type {
+		case "any":
+			out.ToolChoice = jsonString("required")
+		case "none":
+			out.ToolChoice = jsonString("none")
+		case "tool":
+			out.ToolChoice, _ = json.Marshal(map[string]interface{}{
+				"type":     "function",
+				"function": map[string]string{"name": req.ToolChoice.Name},
+			})
+		default:
+			out.ToolChoice = jsonString("auto")
+		}
+		if req.ToolChoice.DisableParallelToolUse {
+			parallel := false
+			out.ParallelToolCalls = &parallel
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// claudeAssistantToOpenAI converts an assistant turn's text and tool_use blocks
+func claudeAssistantToOpenAI(blocks []claudeContentBlock) openAIMessage {
+	msg := openAIMessage{Role: "assistant"}
+
+	var text strings.Builder
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			input := block.Input
+			if len(input) == 0 {
+				input = json.RawMessage(`{}`)
+			}
+			msg.ToolCalls = append(msg.ToolCalls, openAIToolCall{
+				ID:   block.ID,
+				Type: "function",
+				Function: openAIFunctionCall{
+					Name:      block.Name,
+					Arguments: string(input),
+				},
+			})
+		}
+	}
+
+	if text.Len() > 0 || len(msg.ToolCalls) == 0 {
+		msg.Content = jsonString(text.String())
+	} else {
+		msg.Content = json.RawMessage("null")
+	}
+	return msg
+}
+
+// openAIUserContent uses the plain string form when the content is text only
+func openAIUserContent(parts []openAIContentPart) json.RawMessage {
+	var text strings.Builder
+	for _, part := range parts {
+		if part.Type != "text" {
+			content, _ := json.Marshal(parts)
+			return content
+		}
+		if text.Len() > 0 {
+			text.WriteString("\n")
+		}
+		text.WriteString(part.Text)
+	}
+	return jsonString(text.String())
+}
+
+// claudeSourceToImageURL converts a Claude image source into an image_url value
+func claudeSourceToImageURL(source *claudeImageSource) string {
+	if source == nil {
+		return ""
+	}
+	if source.Type == "base64" {
+		return "data:" + source.MediaType + ";base64," + source.Data
+	}
+	return source.URL
+}
+
+// openAIToClaudeResponse converts a Chat Completions response into a Messages response
+func openAIToClaudeResponse(body []byte, model string) ([]byte, error) {
+	var resp openAIChatResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid chat completions response: %w", err)
+	}
+
+	out := claudeResponse{
+		ID:      "msg_" + strings.TrimPrefix(resp.ID, "chatcmpl-"),
+		Type:    "message",
+		Role:    "assistant",
+		Model:   model,
+		Content: []claudeContentBlock{},
+	}
+
+	stopReason := "end_turn"
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		if choice.Message != nil {
+			if text := openAIContentText(choice.Message.Content); text != "" {
+				out.Content = append(out.Content, claudeContentBlock{Type: "text", Text: text})
+			}
+			for _, call := range choice.Message.ToolCalls {
+				out.Content = append(out.Content, claudeContentBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: toolArgumentsToInput(call.Function.Arguments),
+				})
+			}
+		}
+		if choice.FinishReason != nil {
+			stopReason = openAIToClaudeStopReason(*choice.FinishReason)
+		}
+	}
+	out.StopReason = &stopReason
+
+	if resp.Usage != nil {
+		out.Usage = openAIToClaudeUsage(resp.Usage)
+	}
+	return json.Marshal(out)
+}
+
+// openAIToClaudeUsage maps OpenAI usage to Anthropic usage, splitting out cached prompt tokens
+func openAIToClaudeUsage(usage *openAIUsage) claudeUsage {
+	out := claudeUsage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+	}
+	if usage.PromptTokensDetails != nil && usage.PromptTokensDetails.CachedTokens > 0 {
+		out.CacheReadInputTokens = usage.PromptTokensDetails.CachedTokens
+		out.InputTokens -= usage.PromptTokensDetails.CachedTokens
+	}
+	return out
+}
+
+// openAIToClaudeStream converts Chat Completions chunks into Anthropic SSE events.
+// Claude content blocks are strictly sequential, so a block is closed before the next opens.
+type openAIToClaudeStream struct {
+	model      string
+	started    bool
+	finished   bool
+	openIndex  int         // Index of the open content block, -1 if none
+	openTool   int         // OpenAI tool call index of the open block, -1 for text
+	nextIndex  int         // Next Claude content block index
+	toolBlocks map[int]int // OpenAI tool call index -> Claude block index
+	stopReason string
+	usage      *openAIUsage
+}
+
+func newOpenAIToClaudeStream(model string) StreamConverter {
+	return &openAIToClaudeStream{
+		model:      model,
+		openIndex:  -1,
+		openTool:   -1,
+		toolBlocks: make(map[int]int),
+	}
+}
+
+// claudeEvent builds a named Anthropic SSE event
+func claudeEvent(name string, payload map[string]interface{}) Event {
+	payload["type"] = name
+	data, _ := json.Marshal(payload)
+	return Event{Name: name, Data: data}
+}
+
+func (c *openAIToClaudeStream) start(id string) []Event {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	return []Event{claudeEvent("message_start", map[string]interface{}{
+		"message": map[string]interface{}{
+			"id":            "msg_" + strings.TrimPrefix(id, "chatcmpl-"),
+			"type":          "message",
+			"role":          "assistant",
+			"model":         c.model,
+			"content":       []interface{}{},
+			"stop_reason":   nil,
+			"stop_sequence": nil,
+			"usage":         map[string]int64{"input_tokens": 0, "output_tokens": 0},
+		},
+	})}
+}
+
+func (c *openAIToClaudeStream) closeBlock() []Event {
+	if c.openIndex < 0 {
+		return nil
+	}
+	index := c.openIndex
+	c.openIndex, c.openTool = -1, -1
+	return []Event{claudeEvent("content_block_stop", map[string]interface{}{"index": index})}
+}
+
+func (c *openAIToClaudeStream) Convert(ev Event) []Event {
+	if string(ev.Data) == "[DONE]" {
+		return c.finish()
+	}
+
+	var chunk struct {
+		openAIChatResponse
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal(ev.Data, &chunk); err != nil {
+		return nil
+	}
+	if chunk.Error != nil {
+		return []Event{claudeEvent("error", map[string]interface{}{
+			"error": map[string]string{"type": "api_error", "message": chunk.Error.Message},
+		})}
+	}
+
+	events := c.start(chunk.ID)
+	if chunk.Usage != nil {
+		c.usage = chunk.Usage
+	}
+
+	for _, choice := range chunk.Choices {
+		if choice.Delta != nil {
+			events = append(events, c.convertDelta(choice.Delta)...)
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			c.stopReason = openAIToClaudeStopReason(*choice.FinishReason)
+			events = append(events, c.closeBlock()...)
+		}
+	}
+	return events
+}
+
+func (c *openAIToClaudeStream) convertDelta(delta *openAIMessage) []Event {
+	var events []Event
+
+	if text := openAIContentText(delta.Content); text != "" {
+		if c.openIndex < 0 || c.openTool >= 0 {
+			events = append(events, c.closeBlock()...)
+			c.openIndex, c.openTool = c.nextIndex, -1
+			c.nextIndex++
+			events = append(events, claudeEvent("content_block_start", map[string]interface{}{
+				"index":         c.openIndex,
+				"content_block": map[string]string{"type": "text", "text": ""},
+			}))
+		}
+		events = append(events, claudeEvent("content_block_delta", map[string]interface{}{
+			"index": c.openIndex,
+			"delta": map[string]string{"type": "text_delta", "text": text},
+		}))
+	}
+
+	for i, call := range delta.ToolCalls {
+		toolIndex := i
+		if call.Index != nil {
+			toolIndex = *call.Index
+		}
+
+		blockIndex, known := c.toolBlocks[toolIndex]
+		if !known {
+			events = append(events, c.closeBlock()...)
+			blockIndex = c.nextIndex
+			c.nextIndex++
+			c.toolBlocks[toolIndex] = blockIndex
+			c.openIndex, c.openTool = blockIndex, toolIndex
+			events = append(events, claudeEvent("content_block_start", map[string]interface{}{
+				"index": blockIndex,
+				"content_block": map[string]interface{}{
+					"type":  "tool_use",
+					"id":    call.ID,
+					"name":  call.Function.Name,
+					"input": map[string]interface{}{},
+				},
+			}))
+		}
+
+		if call.Function.Arguments != "" {
+			events = append(events, claudeEvent("content_block_delta", map[string]interface{}{
+				"index": blockIndex,
+				"delta": map[string]string{"type": "input_json_delta", "partial_json": call.Function.Arguments},
+			}))
+		}
+	}
+
+	return events
+}
+
+// finish closes any open block and emits message_delta (stop reason and usage) and message_stop
+func (c *openAIToClaudeStream) finish() []Event {
+	if c.finished {
+		return nil
+	}
+	c.finished = true
+
+	events := c.start("")
+	events = append(events, c.closeBlock()...)
+
+	stopReason := c.stopReason
+	if stopReason == "" {
+		stopReason = "end_turn"
+	}
+	usage := map[string]int64{"output_tokens": 0}
+	if c.usage != nil {
+		converted := openAIToClaudeUsage(c.usage)
+		usage["input_tokens"] = converted.InputTokens
+		usage["output_tokens"] = converted.OutputTokens
+		if converted.CacheReadInputTokens > 0 {
+			usage["cache_read_input_tokens"] = converted.CacheReadInputTokens
+		}
+	}
+
+	events = append(events,
+		claudeEvent("message_delta", map[string]interface{}{
+			"delta": map[string]interface{}{"stop_reason": stopReason, "stop_sequence": nil},
+			"usage": usage,
+		}),
+		claudeEvent("message_stop", map[string]interface{}{}),
+	)
+	return events
+}
+
+func (c *openAIToClaudeStream) Finish() []Event {
+	return c.finish()
+}
@@ -0,0 +1,131 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ErrorBody renders an error in the given format's native error shape
+func ErrorBody(format string, status int, errType string, message string) []byte {
+	var body []byte
+	switch format {
+	case FormatAnthropic:
+		body, _ = json.Marshal(claudeErrorResponse{
+			Type:  "error",
+			Error: claudeError{Type: errType, Message: message},
+		})
+	case FormatGemini:
+		body, _ = json.Marshal(geminiErrorResponse{Error: geminiError{
+			Code:    status,
+			Message: message,
+			Status:  errType,
+		}})
+	default:
+		body, _ = json.Marshal(openAIErrorResponse{Error: openAIError{
+			Message: message,
+			Type:    errType,
+		}})
+	}
+	return body
+}
+
+// StatusError renders an error in the format's native shape, with the error
+// type that format conventionally uses for the HTTP status
+func StatusError(format string, status int, message string) []byte {
+	return ErrorBody(format, status, errorTypeForStatus(format, status), message)
+}
+
+// ContextLengthError renders the error the format's provider returns when a
+// prompt of the given token count exceeds a model's context window
+func ContextLengthError(format string, tokens, window int) []byte {
+	switch format {
+	case FormatAnthropic:
+		message := fmt.Sprintf("prompt is too long: %d tokens > %d maximum", tokens, window)
+		return ErrorBody(format, http.StatusBadRequest, "invalid_request_error", message)
+	case FormatGemini:
+		message := fmt.Sprintf("The input token count (%d) exceeds the maximum number of tokens allowed (%d).", tokens, window)
+		return ErrorBody(format, http.StatusBadRequest, geminiStatusForHTTP(http.StatusBadRequest), message)
+	default:
+		body, _ := json.Marshal(openAIErrorResponse{Error: openAIError{
+			Message: fmt.Sprintf("This model's maximum context length is %d tokens. However, your messages resulted in %d tokens. Please reduce the length of the messages.", window, tokens),
+			Type:    "invalid_request_error",
+			Code:    "context_length_exceeded",
+		}})
+		return body
+	}
+}
+
+// TranslateError converts an upstream error body into the client's error shape,
+// keeping the upstream message when one can be found
+func TranslateError(from, to string, status int, body []byte) []byte {
+	if from == to {
+		return body
+	}
+
+	message := extractErrorMessage(body)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return ErrorBody(to, status, errorTypeForStatus(to, status), message)
+}
+
+// extractErrorMessage pulls a human readable message out of any provider's error body
+func extractErrorMessage(body []byte) string {
+	var data struct {
+		Error   json.RawMessage `json:"error"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return string(body)
+	}
+
+	var nested struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(data.Error, &nested); err == nil && nested.Message != "" {
+		return nested.Message
+	}
+
+	var plain string
+	if err := json.Unmarshal(data.Error, &plain); err == nil && plain != "" {
+		return plain
+	}
+	return data.Message
+}
+
+// errorTypeForStatus picks the format's conventional error type for an HTTP status
+func errorTypeForStatus(format string, status int) string {
+	if format == FormatGemini {
+		return geminiStatusForHTTP(status)
+	}
+	if format == FormatAnthropic {
+		switch {
+		case status == http.StatusUnauthorized:
+			return "authentication_error"
+		case status == http.StatusForbidden:
+			return "permission_error"
+		case status == http.StatusNotFound:
+			return "not_found_error"
+		case status == http.StatusTooManyRequests:
+			return "rate_limit_error"
+		case status == 529 || status == http.StatusServiceUnavailable:
+			return "overloaded_error"
+		case status >= 500:
+			return "api_error"
+		default:
+			return "invalid_request_error"
+		}
+	}
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return "authentication_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_exceeded"
+	case status >= 500:
+		return "server_error"
+	default:
+		return "invalid_request_error"
+	}
+}
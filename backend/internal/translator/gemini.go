@@ -0,0 +1,229 @@
+package translator
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Google generateContent wire types
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // base64
+}
+
+type geminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations,omitempty"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	Parameters           json.RawMessage `json:"parameters,omitempty"`           // OpenAPI subset schema
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"` // Full JSON Schema
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig *geminiFunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+type geminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode,omitempty"` // AUTO, ANY, NONE
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens *int     `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates    []geminiCandidate `json:"candidates"`
+	UsageMetadata *geminiUsage      `json:"usageMetadata,omitempty"`
+	ModelVersion  string            `json:"modelVersion,omitempty"`
+	ResponseID    string            `json:"responseId,omitempty"`
+}
+
+type geminiCandidate struct {
+	Content      geminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"`
+	Index        int           `json:"index"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount        int64 `json:"promptTokenCount"`
+	CandidatesTokenCount    int64 `json:"candidatesTokenCount"`
+	TotalTokenCount         int64 `json:"totalTokenCount"`
+	CachedContentTokenCount int64 `json:"cachedContentTokenCount,omitempty"`
+	ThoughtsTokenCount      int64 `json:"thoughtsTokenCount,omitempty"`
+}
+
+type geminiErrorResponse struct {
+	Error geminiError `json:"error"`
+}
+
+type geminiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+// geminiToOpenAIFinishReason maps a Gemini finishReason onto an OpenAI finish_reason
+func geminiToOpenAIFinishReason(reason string) string {
+	switch reason {
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+// openAIToGeminiFinishReason maps an OpenAI finish_reason onto a Gemini finishReason
+func openAIToGeminiFinishReason(reason string) string {
+	switch reason {
+	case "length":
+		return "MAX_TOKENS"
+	case "content_filter":
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
+// geminiStatusForHTTP returns the google.rpc status name Gemini uses for an HTTP status
+func geminiStatusForHTTP(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case http.StatusServiceUnavailable, 529:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	}
+	if status >= 500 {
+		return "INTERNAL"
+	}
+	return "INVALID_ARGUMENT"
+}
+
+// walkSchema calls fn on every schema node of a JSON schema. The keys of
+// "properties" are property names, so only their values are visited.
+func walkSchema(node interface{}, fn func(map[string]interface{})) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		fn(v)
+		for key, child := range v {
+			if props, ok := child.(map[string]interface{}); ok && key == "properties" {
+				for _, prop := range props {
+					walkSchema(prop, fn)
+				}
+				continue
+			}
+			walkSchema(child, fn)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkSchema(child, fn)
+		}
+	}
+}
+
+// rewriteSchema decodes a schema, applies fn to every object node and re-encodes it
+func rewriteSchema(raw json.RawMessage, fn func(map[string]interface{})) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var schema interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return raw
+	}
+	walkSchema(schema, fn)
+	out, err := json.Marshal(schema)
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+// toGeminiSchema drops JSON Schema keywords that Gemini's OpenAPI subset rejects
+func toGeminiSchema(raw json.RawMessage) json.RawMessage {
+	return rewriteSchema(raw, func(node map[string]interface{}) {
+		for _, key := range []string{"$schema", "$id", "additionalProperties", "strict"} {
+			delete(node, key)
+		}
+	})
+}
+
+// fromGeminiSchema lowercases Gemini's enum-style types (OBJECT, STRING, ...) for JSON Schema
+func fromGeminiSchema(raw json.RawMessage) json.RawMessage {
+	return rewriteSchema(raw, func(node map[string]interface{}) {
+		if t, ok := node["type"].(string); ok {
+			node["type"] = strings.ToLower(t)
+		}
+	})
+}
+
+// geminiStreamUsage reads usageMetadata, which is cumulative so the last chunk wins
+func geminiStreamUsage(ev Event, usage *StreamUsage) {
+	var chunk struct {
+		UsageMetadata *geminiUsage `json:"usageMetadata"`
+	}
+	if err := json.Unmarshal(ev.Data, &chunk); err != nil || chunk.UsageMetadata == nil {
+		return
+	}
+	usage.InputTokens = chunk.UsageMetadata.PromptTokenCount
+	usage.OutputTokens = chunk.UsageMetadata.CandidatesTokenCount + chunk.UsageMetadata.ThoughtsTokenCount
+	usage.TotalTokens = chunk.UsageMetadata.TotalTokenCount
+}
@@ -0,0 +1,345 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Gemini generateContent clients served by OpenAI Chat Completions upstreams
+
+func init() {
+	register(FormatGemini, FormatOpenAI, geminiToOpenAIRequest, openAIToGeminiResponse, newOpenAIToGeminiStream)
+}
+
+// geminiToOpenAIRequest converts a generateContent request into a Chat Completions request
+func geminiToOpenAIRequest(body []byte, model string, stream bool) ([]byte, error) {
+	var req geminiRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid generateContent request: %w", err)
+	}
+
+	out := openAIChatRequest{
+		Model:  model,
+		Stream: stream,
+	}
+	if stream {
+		out.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	if config := req.GenerationConfig; config != nil {
+		out.Temperature = config.Temperature
+		out.TopP = config.TopP
+		out.MaxTokens = config.MaxOutputTokens
+		if len(config.StopSequences) > 0 {
+			out.Stop, _ = json.Marshal(config.StopSequences)
+		}
+	}
+
+	if req.SystemInstruction != nil {
+		if text := geminiPartsText(req.SystemInstruction.Parts); text != "" {
+			out.Messages = append(out.Messages, openAIMessage{Role: "system", Content: jsonString(text)})
+		}
+	}
+
+	// Gemini function calls often carry no ID; responses are matched to calls by name, in order
+	pending := make(map[string][]string)
+	calls := 0
+	for _, content := range req.Contents {
+		if content.Role == "model" {
+			msg := openAIMessage{Role: "assistant"}
+			var text strings.Builder
+			for _, part := range content.Parts {
+				switch {
+				case part.FunctionCall != nil:
+					call := geminiCallToOpenAI(part.FunctionCall, calls)
+					calls++
+					pending[call.Function.Name] = append(pending[call.Function.Name], call.ID)
+					msg.ToolCalls = append(msg.ToolCalls, call)
+				case !part.Thought:
+					text.WriteString(part.Text)
+				}
+			}
+			if text.Len() > 0 || len(msg.ToolCalls) == 0 {
+				msg.Content = jsonString(text.String())
+			} else {
+				msg.Content = json.RawMessage("null")
+			}
+			out.Messages = append(out.Messages, msg)
+			continue
+		}
+
+		// Function responses become tool messages ahead of the rest of the user turn
+		var parts []openAIContentPart
+		for _, part := range content.Parts {
+			switch {
+			case part.FunctionResponse != nil:
+				id := part.FunctionResponse.ID
+				if queue := pending[part.FunctionResponse.Name]; len(queue) > 0 {
+					if id == "" {
+						id = queue[0]
+					}
+					pending[part.FunctionResponse.Name] = queue[1:]
+				}
+				out.Messages = append(out.Messages, openAIMessage{
+					Role:       "tool",
+					ToolCallID: id,
+					Content:    jsonString(string(part.FunctionResponse.Response)),
+				})
+			case part.InlineData != nil:
+				parts = append(parts, openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{
+					URL: "data:" + part.InlineData.MimeType + ";base64," + part.InlineData.Data,
+				}})
+			case part.FileData != nil:
+				parts = append(parts, openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: part.FileData.FileURI}})
+			case part.Text != "" && !part.Thought:
+				parts = append(parts, openAIContentPart{Type: "text", Text: part.Text})
+			}
+		}
+		if len(parts) > 0 {
+			out.Messages = append(out.Messages, openAIMessage{Role: "user", Content: openAIUserContent(parts)})
+		}
+	}
+
+	for _, tool := range req.Tools {
+		for _, decl := range tool.FunctionDeclarations {
+			params := decl.ParametersJSONSchema
+			if len(params) == 0 {
+				params = fromGeminiSchema(decl.Parameters)
+			}
+			out.Tools = append(out.Tools, openAITool{
+				Type: "function",
+				Function: openAIFunction{
+					Name:        decl.Name,
+					Description: decl.Description,
+					Parameters:  params,
+				},
+			})
+		}
+	}
+
+	if len(out.Tools) > 0 && req.ToolConfig != nil && req.ToolConfig.FunctionCallingConfig != nil {
+		config := req.ToolConfig.FunctionCallingConfig
+		switch config.Mode {
+		case "ANY":
+			if len(config.AllowedFunctionNames) == 1 {
+				out.ToolChoice, _ = json.Marshal(map[string]interface{}{
+					"type":     "function",
+					"function": map[string]string{"name": config.AllowedFunctionNames[0]},
+				})
+			} else {
+				out.ToolChoice = jsonString("required")
+			}
+		case "NONE":
+			out.ToolChoice = jsonString("none")
+		case "AUTO":
+			out.ToolChoice = jsonString("auto")
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// geminiPartsText concatenates the text parts of a Gemini content
+func geminiPartsText(parts []geminiPart) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Text != "" && !part.Thought {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// openAIToGeminiResponse converts a Chat Completions response into a generateContent response
+func openAIToGeminiResponse(body []byte, model string) ([]byte, error) {
+	var resp openAIChatResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid chat completions response: %w", err)
+	}
+
+	candidate := geminiCandidate{
+		Content:      geminiContent{Role: "model", Parts: []geminiPart{}},
+		FinishReason: "STOP",
+	}
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		if choice.Message != nil {
+			if choice.Message.ReasoningContent != "" {
+				candidate.Content.Parts = append(candidate.Content.Parts, geminiPart{Text: choice.Message.ReasoningContent, Thought: true})
+			}
+			if text := openAIContentText(choice.Message.Content); text != "" {
+				candidate.Content.Parts = append(candidate.Content.Parts, geminiPart{Text: text})
+			}
+			for _, call := range choice.Message.ToolCalls {
+				candidate.Content.Parts = append(candidate.Content.Parts, openAICallToGemini(call))
+			}
+		}
+		if choice.FinishReason != nil {
+			candidate.FinishReason = openAIToGeminiFinishReason(*choice.FinishReason)
+		}
+	}
+
+	out := geminiResponse{
+		Candidates:   []geminiCandidate{candidate},
+		ModelVersion: model,
+		ResponseID:   strings.TrimPrefix(resp.ID, "chatcmpl-"),
+	}
+	if resp.Usage != nil {
+		out.UsageMetadata = openAIToGeminiUsage(resp.Usage)
+	}
+	return json.Marshal(out)
+}
+
+// openAICallToGemini converts a complete tool call into a functionCall part
+func openAICallToGemini(call openAIToolCall) geminiPart {
+	return geminiPart{FunctionCall: &geminiFunctionCall{
+		ID:   call.ID,
+		Name: call.Function.Name,
+		Args: toolArgumentsToInput(call.Function.Arguments),
+	}}
+}
+
+// openAIToGeminiUsage maps OpenAI usage onto usageMetadata
+func openAIToGeminiUsage(usage *openAIUsage) *geminiUsage {
+	out := &geminiUsage{
+		PromptTokenCount:     usage.PromptTokens,
+		CandidatesTokenCount: usage.CompletionTokens,
+		TotalTokenCount:      usage.TotalTokens,
+	}
+	if out.TotalTokenCount == 0 {
+		out.TotalTokenCount = usage.PromptTokens + usage.CompletionTokens
+	}
+	if usage.PromptTokensDetails != nil {
+		out.CachedContentTokenCount = usage.PromptTokensDetails.CachedTokens
+	}
+	return out
+}
+
+// openAIToGeminiStream converts Chat Completions chunks into streamGenerateContent chunks.
+// Text is forwarded as it arrives; tool call arguments are streamed in fragments,
+// so calls are buffered and sent whole with the final chunk.
+type openAIToGeminiStream struct {
+	model     string
+	id        string
+	toolCalls []openAIToolCall
+	finish    string
+	usage     *openAIUsage
+	done      bool
+}
+
+func newOpenAIToGeminiStream(model string) StreamConverter {
+	return &openAIToGeminiStream{model: model}
+}
+
+func (c *openAIToGeminiStream) chunk(parts []geminiPart, finish string, usage *openAIUsage) Event {
+	resp := geminiResponse{
+		Candidates: []geminiCandidate{{
+			Content:      geminiContent{Role: "model", Parts: parts},
+			FinishReason: finish,
+		}},
+		ModelVersion: c.model,
+		ResponseID:   c.id,
+	}
+	if usage != nil {
+		resp.UsageMetadata = openAIToGeminiUsage(usage)
+	}
+	data, _ := json.Marshal(resp)
+	return Event{Data: data}
+}
+
+func (c *openAIToGeminiStream) Convert(ev Event) []Event {
+	if string(ev.Data) == "[DONE]" {
+		return c.Finish()
+	}
+
+	var chunk struct {
+		openAIChatResponse
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal(ev.Data, &chunk); err != nil {
+		return nil
+	}
+	if chunk.Error != nil {
+		data, _ := json.Marshal(geminiErrorResponse{Error: geminiError{
+			Code:    500,
+			Message: chunk.Error.Message,
+			Status:  "INTERNAL",
+		}})
+		return []Event{{Data: data}}
+	}
+
+	if c.id == "" {
+		c.id = strings.TrimPrefix(chunk.ID, "chatcmpl-")
+	}
+	if chunk.Usage != nil {
+		c.usage = chunk.Usage
+	}
+
+	var events []Event
+	for _, choice := range chunk.Choices {
+		if delta := choice.Delta; delta != nil {
+			var parts []geminiPart
+			if delta.ReasoningContent != "" {
+				parts = append(parts, geminiPart{Text: delta.ReasoningContent, Thought: true})
+			}
+			if text := openAIContentText(delta.Content); text != "" {
+				parts = append(parts, geminiPart{Text: text})
+			}
+			if len(parts) > 0 {
+				events = append(events, c.chunk(parts, "", nil))
+			}
+			c.bufferToolCalls(delta.ToolCalls)
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			c.finish = openAIToGeminiFinishReason(*choice.FinishReason)
+		}
+	}
+	return events
+}
+
+// bufferToolCalls accumulates streamed tool call fragments by index
+func (c *openAIToGeminiStream) bufferToolCalls(calls []openAIToolCall) {
+	for i, call := range calls {
+		index := i
+		if call.Index != nil {
+			index = *call.Index
+		}
+		for len(c.toolCalls) <= index {
+			c.toolCalls = append(c.toolCalls, openAIToolCall{})
+		}
+		buffered := &c.toolCalls[index]
+		if call.ID != "" {
+			buffered.ID = call.ID
+		}
+		if call.Function.Name != "" {
+			buffered.Function.Name = call.Function.Name
+		}
+		buffered.Function.Arguments += call.Function.Arguments
+	}
+}
+
+// Finish emits the buffered tool calls with the finish reason and usage
+func (c *openAIToGeminiStream) Finish() []Event {
+	if c.done {
+		return nil
+	}
+	c.done = true
+
+	parts := []geminiPart{}
+	for i, call := range c.toolCalls {
+		if call.Function.Name == "" {
+			continue
+		}
+		if call.ID == "" {
+			call.ID = "call_" + strconv.Itoa(i)
+		}
+		parts = append(parts, openAICallToGemini(call))
+	}
+
+	finish := c.finish
+	if finish == "" {
+		finish = "STOP"
+	}
+	return []Event{c.chunk(parts, finish, c.usage)}
+}
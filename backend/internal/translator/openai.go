@@ -0,0 +1,178 @@
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// OpenAI Chat Completions wire types
+
+type openAIChatRequest struct {
+	Model               string               `json:"model"`
+	Messages            []openAIMessage      `json:"messages"`
+	MaxTokens           *int                 `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int                 `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64             `json:"temperature,omitempty"`
+	TopP                *float64             `json:"top_p,omitempty"`
+	Stop                json.RawMessage      `json:"stop,omitempty"` // string or []string
+	Stream              bool                 `json:"stream,omitempty"`
+	StreamOptions       *openAIStreamOptions `json:"stream_options,omitempty"`
+	Tools               []openAITool         `json:"tools,omitempty"`
+	ToolChoice          json.RawMessage      `json:"tool_choice,omitempty"` // string or object
+	ParallelToolCalls   *bool                `json:"parallel_tool_calls,omitempty"`
+	User                string               `json:"user,omitempty"`
+}
+
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAIMessage struct {
+	Role             string           `json:"role,omitempty"`
+	Content          json.RawMessage  `json:"content,omitempty"` // string, []openAIContentPart or null
+	Name             string           `json:"name,omitempty"`
+	ToolCalls        []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID       string           `json:"tool_call_id,omitempty"`
+	ReasoningContent string           `json:"reasoning_content,omitempty"`
+}
+
+type openAIContentPart struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	ImageURL *openAIImageURL `json:"image_url,omitempty"`
+}
+
+type openAIImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type openAIToolCall struct {
+	Index    *int               `json:"index,omitempty"` // Only set in streaming deltas
+	ID       string             `json:"id,omitempty"`
+	Type     string             `json:"type,omitempty"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+type openAIFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+type openAIFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type openAIChatResponse struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []openAIChoice `json:"choices"`
+	Usage   *openAIUsage   `json:"usage,omitempty"`
+}
+
+type openAIChoice struct {
+	Index        int            `json:"index"`
+	Message      *openAIMessage `json:"message,omitempty"`
+	Delta        *openAIMessage `json:"delta,omitempty"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+type openAIUsage struct {
+	PromptTokens        int64                `json:"prompt_tokens"`
+	CompletionTokens    int64                `json:"completion_tokens"`
+	TotalTokens         int64                `json:"total_tokens"`
+	PromptTokensDetails *openAITokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+type openAITokensDetails struct {
+	CachedTokens int64 `json:"cached_tokens"`
+}
+
+type openAIErrorResponse struct {
+	Error openAIError `json:"error"`
+}
+
+type openAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+// parseOpenAIContent normalizes message content into parts (plain strings become one text part)
+func parseOpenAIContent(raw json.RawMessage) []openAIContentPart {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		if text == "" {
+			return nil
+		}
+		return []openAIContentPart{{Type: "text", Text: text}}
+	}
+
+	var parts []openAIContentPart
+	json.Unmarshal(raw, &parts)
+	return parts
+}
+
+// openAIContentText concatenates the text parts of message content
+func openAIContentText(raw json.RawMessage) string {
+	var sb strings.Builder
+	for _, part := range parseOpenAIContent(raw) {
+		if part.Type == "text" {
+			sb.WriteString(part.Text)
+		}
+	}
+	return sb.String()
+}
+
+// parseStopSequences accepts the string or array form of the stop field
+func parseStopSequences(raw json.RawMessage) []string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}
+	}
+
+	var list []string
+	json.Unmarshal(raw, &list)
+	return list
+}
+
+// jsonString marshals a string into a raw JSON value
+func jsonString(s string) json.RawMessage {
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// stringPtr returns a pointer to s
+func stringPtr(s string) *string {
+	return &s
+}
+
+// openAIStreamUsage reads the usage chunk sent when stream_options.include_usage is set
+func openAIStreamUsage(ev Event, usage *StreamUsage) {
+	var chunk struct {
+		Usage *openAIUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(ev.Data, &chunk); err != nil || chunk.Usage == nil {
+		return
+	}
+	usage.InputTokens = chunk.Usage.PromptTokens
+	usage.OutputTokens = chunk.Usage.CompletionTokens
+	usage.TotalTokens = chunk.Usage.TotalTokens
+}
@@ -0,0 +1,401 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OpenAI Chat Completions clients served by Anthropic Messages upstreams
+
+func init() {
+	register(FormatOpenAI, FormatAnthropic, openAIToClaudeRequest, claudeToOpenAIResponse, newClaudeToOpenAIStream)
+}
+
+// defaultClaudeMaxTokens is sent when an OpenAI request doesn't set a limit,
+// since Anthropic requires max_tokens
+const defaultClaudeMaxTokens = 8192
+
+// openAIToClaudeRequest converts a Chat Completions request into a Messages request
+func openAIToClaudeRequest(body []byte, model string, stream bool) ([]byte, error) {
+	var req openAIChatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid chat completions request: %w", err)
+	}
+
+	out := claudeRequest{
+		Model:         model,
+		MaxTokens:     defaultClaudeMaxTokens,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: parseStopSequences(req.Stop),
+		Stream:        stream,
+	}
+	if req.MaxCompletionTokens != nil {
+		out.MaxTokens = *req.MaxCompletionTokens
+	} else if req.MaxTokens != nil {
+		out.MaxTokens = *req.MaxTokens
+	}
+	if req.User != "" {
+		out.Metadata = &claudeMetadata{UserID: req.User}
+	}
+
+	// System and developer messages become the top-level system prompt
+	var system []string
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			if text := openAIContentText(msg.Content); text != "" {
+				system = append(system, text)
+			}
+		case "user":
+			out.Messages = appendClaudeBlocks(out.Messages, "user", openAIPartsToClaude(msg.Content))
+		case "assistant":
+			var blocks []claudeContentBlock
+			if text := openAIContentText(msg.Content); text != "" {
+				blocks = append(blocks, claudeContentBlock{Type: "text", Text: text})
+			}
+			for _, call := range msg.ToolCalls {
+				blocks = append(blocks, claudeContentBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Function.Name,
+					Input: toolArgumentsToInput(call.Function.Arguments),
+				})
+			}
+			out.Messages = appendClaudeBlocks(out.Messages, "assistant", blocks)
+		case "tool", "function":
+			// Tool results are sent back to Claude as user content blocks
+			out.Messages = appendClaudeBlocks(out.Messages, "user", []claudeContentBlock{{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   jsonString(openAIContentText(msg.Content)),
+			}})
+		}
+	}
+	if len(system) > 0 {
+		out.System = jsonString(strings.Join(system, "\n\n"))
+	}
+
+	for _, tool := range req.Tools {
+		if tool.Type != "" && tool.Type != "function" {
+			continue
+		}
+		schema := tool.Function.Parameters
+		if len(schema) == 0 || string(schema) == "null" {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		out.Tools = append(out.Tools, claudeTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	out.ToolChoice = openAIToolChoiceToClaude(req.ToolChoice)
+	if req.ParallelToolCalls != nil && !*req.ParallelToolCalls && len(out.Tools) > 0 {
+		if out.ToolChoice == nil {
+			out.ToolChoice = &claudeToolChoice{Type: "auto"}
+		}
+		out.ToolChoice.DisableParallelToolUse = true
+	}
+
+	return json.Marshal(out)
+}
+
+// appendClaudeBlocks appends content blocks, merging consecutive messages of the
+// same role since Anthropic requires alternating user/assistant turns
+func appendClaudeBlocks(messages []claudeMessage, role string, blocks []claudeContentBlock) []claudeMessage {
+	if len(blocks) == 0 {
+		return messages
+	}
+
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		existing := parseClaudeContent(messages[n-1].Content)
+		merged, _ := json.Marshal(append(existing, blocks...))
+		messages[n-1].Content = merged
+		return messages
+	}
+
+	content, _ := json.Marshal(blocks)
+	return append(messages, claudeMessage{Role: role, Content: content})
+}
+
+// openAIPartsToClaude converts user content parts (text and images) into Claude blocks
+func openAIPartsToClaude(raw json.RawMessage) []claudeContentBlock {
+	var blocks []claudeContentBlock
+	for _, part := range parseOpenAIContent(raw) {
+		switch part.Type {
+		case "text":
+			if part.Text != "" {
+				blocks = append(blocks, claudeContentBlock{Type: "text", Text: part.Text})
+			}
+		case "image_url":
+			if part.ImageURL == nil {
+				continue
+			}
+			blocks = append(blocks, claudeContentBlock{
+				Type:   "image",
+				Source: imageURLToClaudeSource(part.ImageURL.URL),
+			})
+		}
+	}
+	return blocks
+}
+
+// imageURLToClaudeSource converts data URLs to base64 sources and keeps remote URLs as-is
+func imageURLToClaudeSource(url string) *claudeImageSource {
+	mediaType, data, ok := parseDataURL(url)
+	if !ok {
+		return &claudeImageSource{Type: "url", URL: url}
+	}
+	return &claudeImageSource{Type: "base64", MediaType: mediaType, Data: data}
+}
+
+// parseDataURL splits a base64 data URL into its media type and payload
+func parseDataURL(url string) (string, string, bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return "", "", false
+	}
+	meta, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok || !strings.HasSuffix(meta, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(meta, ";base64"), data, true
+}
+
+// toolArgumentsToInput turns a JSON-encoded arguments string into a tool_use input object
+func toolArgumentsToInput(arguments string) json.RawMessage {
+	arguments = strings.TrimSpace(arguments)
+	if arguments == "" || !json.Valid([]byte(arguments)) {
+		return json.RawMessage(`{}`)
+	}
+	return json.RawMessage(arguments)
+}
+
+// openAIToolChoiceToClaude maps tool_choice ("auto", "required", "none" or a named function)
+func openAIToolChoiceToClaude(raw json.RawMessage) *claudeToolChoice {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		switch mode {
+		case "required":
+			return &claudeToolChoice{Type: "any"}
+		case "none":
+			return &claudeToolChoice{Type: "none"}
+		default:
+			return &claudeToolChoice{Type: "auto"}
+		}
+	}
+
+	var named struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &named); err == nil && named.Function.Name != "" {
+		return &claudeToolChoice{Type: "tool", Name: named.Function.Name}
+	}
+	return nil
+}
+
+// claudeToOpenAIResponse converts a Messages response into a Chat Completions response
+func claudeToOpenAIResponse(body []byte, model string) ([]byte, error) {
+	var resp claudeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid messages response: %w", err)
+	}
+
+	message := &openAIMessage{Role: "assistant"}
+	var text, reasoning strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "thinking":
+			reasoning.WriteString(block.Thinking)
+		case "tool_use":
+			input := block.Input
+			if len(input) == 0 {
+				input = json.RawMessage(`{}`)
+			}
+			message.ToolCalls = append(message.ToolCalls, openAIToolCall{
+				ID:   block.ID,
+				Type: "function",
+				Function: openAIFunctionCall{
+					Name:      block.Name,
+					Arguments: string(input),
+				},
+			})
+		}
+	}
+	if text.Len() > 0 || len(message.ToolCalls) == 0 {
+		message.Content = jsonString(text.String())
+	} else {
+		message.Content = json.RawMessage("null")
+	}
+	message.ReasoningContent = reasoning.String()
+
+	stopReason := ""
+	if resp.StopReason != nil {
+		stopReason = *resp.StopReason
+	}
+
+	out := openAIChatResponse{
+		ID:      "chatcmpl-" + resp.ID,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openAIChoice{{
+			Index:        0,
+			Message:      message,
+			FinishReason: stringPtr(claudeToOpenAIFinishReason(stopReason)),
+		}},
+		Usage: claudeToOpenAIUsage(resp.Usage),
+	}
+	return json.Marshal(out)
+}
+
+// claudeToOpenAIUsage maps Anthropic token usage (including cache reads) to OpenAI usage
+func claudeToOpenAIUsage(usage claudeUsage) *openAIUsage {
+	prompt := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+	out := &openAIUsage{
+		PromptTokens:     prompt,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      prompt + usage.OutputTokens,
+	}
+	if usage.CacheReadInputTokens > 0 {
+		out.PromptTokensDetails = &openAITokensDetails{CachedTokens: usage.CacheReadInputTokens}
+	}
+	return out
+}
+
+// claudeToOpenAIStream converts Anthropic SSE events into Chat Completions chunks
+type claudeToOpenAIStream struct {
+	model     string
+	id        string
+	created   int64
+	usage     claudeUsage
+	toolIndex map[int]int // Claude content block index -> OpenAI tool call index
+	finish    string
+	done      bool
+}
+
+func newClaudeToOpenAIStream(model string) StreamConverter {
+	return &claudeToOpenAIStream{
+		model:     model,
+		created:   time.Now().Unix(),
+		toolIndex: make(map[int]int),
+	}
+}
+
+func (c *claudeToOpenAIStream) chunk(delta *openAIMessage, finish *string, usage *openAIUsage) Event {
+	data, _ := json.Marshal(openAIChatResponse{
+		ID:      c.id,
+		Object:  "chat.completion.chunk",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []openAIChoice{{Index: 0, Delta: delta, FinishReason: finish}},
+		Usage:   usage,
+	})
+	return Event{Data: data}
+}
+
+func (c *claudeToOpenAIStream) Convert(ev Event) []Event {
+	var event claudeStreamEvent
+	if err := json.Unmarshal(ev.Data, &event); err != nil {
+		return nil
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			c.id = "chatcmpl-" + event.Message.ID
+			c.usage = event.Message.Usage
+		}
+		return []Event{c.chunk(&openAIMessage{Role: "assistant", Content: jsonString("")}, nil, nil)}
+
+	case "content_block_start":
+		if event.ContentBlock == nil || event.ContentBlock.Type != "tool_use" {
+			return nil
+		}
+		index := len(c.toolIndex)
+		c.toolIndex[event.Index] = index
+		return []Event{c.chunk(&openAIMessage{ToolCalls: []openAIToolCall{{
+			Index:    &index,
+			ID:       event.ContentBlock.ID,
+			Type:     "function",
+			Function: openAIFunctionCall{Name: event.ContentBlock.Name},
+		}}}, nil, nil)}
+
+	case "content_block_delta":
+		if event.Delta == nil {
+			return nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			return []Event{c.chunk(&openAIMessage{Content: jsonString(event.Delta.Text)}, nil, nil)}
+		case "thinking_delta":
+			return []Event{c.chunk(&openAIMessage{ReasoningContent: event.Delta.Thinking}, nil, nil)}
+		case "input_json_delta":
+			index, ok := c.toolIndex[event.Index]
+			if !ok {
+				return nil
+			}
+			return []Event{c.chunk(&openAIMessage{ToolCalls: []openAIToolCall{{
+				Index:    &index,
+				Function: openAIFunctionCall{Arguments: event.Delta.PartialJSON},
+			}}}, nil, nil)}
+		}
+
+	case "message_delta":
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			c.finish = claudeToOpenAIFinishReason(event.Delta.StopReason)
+		}
+		if event.Usage != nil {
+			c.usage.OutputTokens = event.Usage.OutputTokens
+			if event.Usage.InputTokens > 0 {
+				c.usage.InputTokens = event.Usage.InputTokens
+			}
+		}
+
+	case "message_stop":
+		return c.finishEvents()
+
+	case "error":
+		if event.Error != nil {
+			data, _ := json.Marshal(openAIErrorResponse{Error: openAIError{
+				Message: event.Error.Message,
+				Type:    event.Error.Type,
+			}})
+			return []Event{{Data: data}}
+		}
+	}
+
+	return nil
+}
+
+// finishEvents emits the final chunk (finish reason plus usage) and the [DONE] marker
+func (c *claudeToOpenAIStream) finishEvents() []Event {
+	if c.done {
+		return nil
+	}
+	c.done = true
+
+	finish := c.finish
+	if finish == "" {
+		finish = "stop"
+	}
+	return []Event{
+		c.chunk(&openAIMessage{}, &finish, claudeToOpenAIUsage(c.usage)),
+		{Data: []byte("[DONE]")},
+	}
+}
+
+func (c *claudeToOpenAIStream) Finish() []Event {
+	return c.finishEvents()
+}
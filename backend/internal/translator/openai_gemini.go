@@ -0,0 +1,366 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAI Chat Completions clients served by Gemini generateContent upstreams
+
+func init() {
+	register(FormatOpenAI, FormatGemini, openAIToGeminiRequest, geminiToOpenAIResponse, newGeminiToOpenAIStream)
+}
+
+// openAIToGeminiRequest converts a Chat Completions request into a generateContent request.
+// The model and streaming mode travel in the upstream path, not the body.
+func openAIToGeminiRequest(body []byte, model string, stream bool) ([]byte, error) {
+	var req openAIChatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid chat completions request: %w", err)
+	}
+
+	var out geminiRequest
+
+	config := &geminiGenerationConfig{
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+		StopSequences: parseStopSequences(req.Stop),
+	}
+	if req.MaxCompletionTokens != nil {
+		config.MaxOutputTokens = req.MaxCompletionTokens
+	} else if req.MaxTokens != nil {
+		config.MaxOutputTokens = req.MaxTokens
+	}
+	if config.Temperature != nil || config.TopP != nil || config.MaxOutputTokens != nil || len(config.StopSequences) > 0 {
+		out.GenerationConfig = config
+	}
+
+	// functionResponse parts must name the function, which tool messages only reference by call ID
+	toolNames := make(map[string]string)
+
+	var system []geminiPart
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			if text := openAIContentText(msg.Content); text != "" {
+				system = append(system, geminiPart{Text: text})
+			}
+		case "user":
+			out.Contents = appendGeminiParts(out.Contents, "user", openAIPartsToGemini(msg.Content))
+		case "assistant":
+			var parts []geminiPart
+			if text := openAIContentText(msg.Content); text != "" {
+				parts = append(parts, geminiPart{Text: text})
+			}
+			for _, call := range msg.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: call.Function.Name,
+					Args: toolArgumentsToInput(call.Function.Arguments),
+				}})
+			}
+			out.Contents = appendGeminiParts(out.Contents, "model", parts)
+		case "tool":
+			name := toolNames[msg.ToolCallID]
+			if name == "" {
+				name = msg.Name
+			}
+			out.Contents = appendGeminiParts(out.Contents, "user", []geminiPart{{
+				FunctionResponse: &geminiFunctionResponse{
+					Name:     name,
+					Response: toolResultToGeminiResponse(openAIContentText(msg.Content)),
+				},
+			}})
+		}
+	}
+	if len(system) > 0 {
+		out.SystemInstruction = &geminiContent{Parts: system}
+	}
+
+	if len(req.Tools) > 0 {
+		var decls []geminiFunctionDeclaration
+		for _, tool := range req.Tools {
+			decls = append(decls, geminiFunctionDeclaration{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  toGeminiSchema(tool.Function.Parameters),
+			})
+		}
+		out.Tools = []geminiTool{{FunctionDeclarations: decls}}
+		out.ToolConfig = openAIToolChoiceToGemini(req.ToolChoice)
+	}
+
+	if out.Contents == nil {
+		out.Contents = []geminiContent{}
+	}
+	return json.Marshal(out)
+}
+
+// appendGeminiParts adds parts to the conversation, merging consecutive turns of the same role
+func appendGeminiParts(contents []geminiContent, role string, parts []geminiPart) []geminiContent {
+	if len(parts) == 0 {
+		return contents
+	}
+	if n := len(contents); n > 0 && contents[n-1].Role == role {
+		contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+		return contents
+	}
+	return append(contents, geminiContent{Role: role, Parts: parts})
+}
+
+// openAIPartsToGemini converts user content parts into text, inlineData and fileData parts
+func openAIPartsToGemini(raw json.RawMessage) []geminiPart {
+	var parts []geminiPart
+	for _, part := range parseOpenAIContent(raw) {
+		switch part.Type {
+		case "text":
+			if part.Text != "" {
+				parts = append(parts, geminiPart{Text: part.Text})
+			}
+		case "image_url":
+			if part.ImageURL == nil {
+				continue
+			}
+			if mediaType, data, ok := parseDataURL(part.ImageURL.URL); ok {
+				parts = append(parts, geminiPart{InlineData: &geminiBlob{MimeType: mediaType, Data: data}})
+			} else {
+				parts = append(parts, geminiPart{FileData: &geminiFileData{
+					MimeType: mime.TypeByExtension(path.Ext(part.ImageURL.URL)),
+					FileURI:  part.ImageURL.URL,
+				}})
+			}
+		}
+	}
+	return parts
+}
+
+// toolResultToGeminiResponse wraps a tool result as the object functionResponse expects
+func toolResultToGeminiResponse(result string) json.RawMessage {
+	trimmed := strings.TrimSpace(result)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	out, _ := json.Marshal(map[string]string{"output": result})
+	return out
+}
+
+// openAIToolChoiceToGemini maps tool_choice onto a function calling mode
+func openAIToolChoiceToGemini(raw json.RawMessage) *geminiToolConfig {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	config := &geminiFunctionCallingConfig{Mode: "AUTO"}
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		switch mode {
+		case "required":
+			config.Mode = "ANY"
+		case "none":
+			config.Mode = "NONE"
+		}
+	} else {
+		var named struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		}
+		if err := json.Unmarshal(raw, &named); err == nil && named.Function.Name != "" {
+			config.Mode = "ANY"
+			config.AllowedFunctionNames = []string{named.Function.Name}
+		}
+	}
+	return &geminiToolConfig{FunctionCallingConfig: config}
+}
+
+// geminiToOpenAIResponse converts a generateContent response into a Chat Completions response
+func geminiToOpenAIResponse(body []byte, model string) ([]byte, error) {
+	var resp geminiResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid generateContent response: %w", err)
+	}
+
+	message := &openAIMessage{Role: "assistant"}
+	finish := "stop"
+	if len(resp.Candidates) > 0 {
+		candidate := resp.Candidates[0]
+		var text, reasoning strings.Builder
+		for _, part := range candidate.Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				message.ToolCalls = append(message.ToolCalls, geminiCallToOpenAI(part.FunctionCall, len(message.ToolCalls)))
+			case part.Thought:
+				reasoning.WriteString(part.Text)
+			default:
+				text.WriteString(part.Text)
+			}
+		}
+		message.Content = jsonString(text.String())
+		message.ReasoningContent = reasoning.String()
+		finish = geminiToOpenAIFinishReason(candidate.FinishReason)
+		if len(message.ToolCalls) > 0 {
+			finish = "tool_calls"
+		}
+	}
+
+	out := openAIChatResponse{
+		ID:      geminiResponseID(resp.ResponseID),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []openAIChoice{{Index: 0, Message: message, FinishReason: &finish}},
+	}
+	if resp.UsageMetadata != nil {
+		out.Usage = geminiToOpenAIUsage(resp.UsageMetadata)
+	}
+	return json.Marshal(out)
+}
+
+// geminiCallToOpenAI converts a functionCall part, inventing a call ID when Gemini omits one
+func geminiCallToOpenAI(call *geminiFunctionCall, index int) openAIToolCall {
+	id := call.ID
+	if id == "" {
+		id = "call_" + strconv.Itoa(index) + "_" + call.Name
+	}
+	args := string(call.Args)
+	if args == "" || args == "null" {
+		args = "{}"
+	}
+	return openAIToolCall{
+		ID:       id,
+		Type:     "function",
+		Function: openAIFunctionCall{Name: call.Name, Arguments: args},
+	}
+}
+
+// geminiResponseID builds a chat completion ID from Gemini's responseId
+func geminiResponseID(id string) string {
+	if id == "" {
+		id = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "chatcmpl-" + id
+}
+
+// geminiToOpenAIUsage maps usageMetadata; thinking tokens are billed as completion tokens
+func geminiToOpenAIUsage(usage *geminiUsage) *openAIUsage {
+	completion := usage.CandidatesTokenCount + usage.ThoughtsTokenCount
+	out := &openAIUsage{
+		PromptTokens:     usage.PromptTokenCount,
+		CompletionTokens: completion,
+		TotalTokens:      usage.PromptTokenCount + completion,
+	}
+	if usage.CachedContentTokenCount > 0 {
+		out.PromptTokensDetails = &openAITokensDetails{CachedTokens: usage.CachedContentTokenCount}
+	}
+	return out
+}
+
+// geminiToOpenAIStream converts streamGenerateContent chunks into Chat Completions chunks.
+// Gemini sends no terminator, so the finish chunk and [DONE] are emitted when the stream ends.
+type geminiToOpenAIStream struct {
+	model     string
+	id        string
+	created   int64
+	started   bool
+	toolCalls int
+	finish    string
+	usage     *geminiUsage
+	done      bool
+}
+
+func newGeminiToOpenAIStream(model string) StreamConverter {
+	return &geminiToOpenAIStream{
+		model:   model,
+		created: time.Now().Unix(),
+	}
+}
+
+func (c *geminiToOpenAIStream) chunk(delta *openAIMessage, finish *string, usage *openAIUsage) Event {
+	data, _ := json.Marshal(openAIChatResponse{
+		ID:      c.id,
+		Object:  "chat.completion.chunk",
+		Created: c.created,
+		Model:   c.model,
+		Choices: []openAIChoice{{Index: 0, Delta: delta, FinishReason: finish}},
+		Usage:   usage,
+	})
+	return Event{Data: data}
+}
+
+func (c *geminiToOpenAIStream) Convert(ev Event) []Event {
+	var resp struct {
+		geminiResponse
+		Error *geminiError `json:"error"`
+	}
+	if err := json.Unmarshal(ev.Data, &resp); err != nil {
+		return nil
+	}
+	if resp.Error != nil {
+		data, _ := json.Marshal(openAIErrorResponse{Error: openAIError{
+			Message: resp.Error.Message,
+			Type:    "server_error",
+		}})
+		return []Event{{Data: data}}
+	}
+
+	var events []Event
+	if !c.started {
+		c.started = true
+		c.id = geminiResponseID(resp.ResponseID)
+		events = append(events, c.chunk(&openAIMessage{Role: "assistant", Content: jsonString("")}, nil, nil))
+	}
+	if resp.UsageMetadata != nil {
+		c.usage = resp.UsageMetadata
+	}
+
+	for _, candidate := range resp.Candidates {
+		for _, part := range candidate.Content.Parts {
+			switch {
+			case part.FunctionCall != nil:
+				index := c.toolCalls
+				c.toolCalls++
+				call := geminiCallToOpenAI(part.FunctionCall, index)
+				call.Index = &index
+				events = append(events, c.chunk(&openAIMessage{ToolCalls: []openAIToolCall{call}}, nil, nil))
+			case part.Thought:
+				if part.Text != "" {
+					events = append(events, c.chunk(&openAIMessage{ReasoningContent: part.Text}, nil, nil))
+				}
+			case part.Text != "":
+				events = append(events, c.chunk(&openAIMessage{Content: jsonString(part.Text)}, nil, nil))
+			}
+		}
+		if candidate.FinishReason != "" {
+			c.finish = geminiToOpenAIFinishReason(candidate.FinishReason)
+		}
+	}
+	return events
+}
+
+func (c *geminiToOpenAIStream) Finish() []Event {
+	if c.done || !c.started {
+		return nil
+	}
+	c.done = true
+
+	finish := c.finish
+	if c.toolCalls > 0 {
+		finish = "tool_calls"
+	} else if finish == "" {
+		finish = "stop"
+	}
+
+	var usage *openAIUsage
+	if c.usage != nil {
+		usage = geminiToOpenAIUsage(c.usage)
+	}
+	return []Event{
+		c.chunk(&openAIMessage{}, &finish, usage),
+		{Data: []byte("[DONE]")},
+	}
+}
@@ -0,0 +1,118 @@
+package translator
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// OpenAI Responses API wire types
+
+type responsesRequest struct {
+	Model             string          `json:"model"`
+	Input             json.RawMessage `json:"input"` // string or []responsesItem
+	Instructions      string          `json:"instructions,omitempty"`
+	Tools             []responsesTool `json:"tools,omitempty"`
+	ToolChoice        json.RawMessage `json:"tool_choice,omitempty"` // string or object
+	ParallelToolCalls *bool           `json:"parallel_tool_calls,omitempty"`
+	MaxOutputTokens   *int            `json:"max_output_tokens,omitempty"`
+	Temperature       *float64        `json:"temperature,omitempty"`
+	TopP              *float64        `json:"top_p,omitempty"`
+	Stream            bool            `json:"stream,omitempty"`
+	User              string          `json:"user,omitempty"`
+}
+
+// responsesItem is an input or output item: a message, function call or function call output
+type responsesItem struct {
+	Type      string          `json:"type,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Status    string          `json:"status,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   json.RawMessage `json:"content,omitempty"` // string or []responsesContent
+	CallID    string          `json:"call_id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Arguments *string         `json:"arguments,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"` // string or []responsesContent
+}
+
+type responsesContent struct {
+	Type     string `json:"type"` // input_text, output_text, input_image, ...
+	Text     string `json:"text,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+type responsesTool struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+type responsesResponse struct {
+	ID                string                      `json:"id"`
+	Object            string                      `json:"object"`
+	CreatedAt         int64                       `json:"created_at"`
+	Status            string                      `json:"status"`
+	Model             string                      `json:"model"`
+	Output            []responsesItem             `json:"output"`
+	Usage             *responsesUsage             `json:"usage,omitempty"`
+	IncompleteDetails *responsesIncompleteDetails `json:"incomplete_details,omitempty"`
+}
+
+type responsesIncompleteDetails struct {
+	Reason string `json:"reason"`
+}
+
+type responsesUsage struct {
+	InputTokens        int64                  `json:"input_tokens"`
+	InputTokensDetails *responsesTokenDetails `json:"input_tokens_details,omitempty"`
+	OutputTokens       int64                  `json:"output_tokens"`
+	TotalTokens        int64                  `json:"total_tokens"`
+}
+
+type responsesTokenDetails struct {
+	CachedTokens int64 `json:"cached_tokens"`
+}
+
+// parseResponsesContent normalizes item content into parts (plain strings become one text part)
+func parseResponsesContent(raw json.RawMessage) []responsesContent {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return []responsesContent{{Type: "input_text", Text: text}}
+	}
+
+	var parts []responsesContent
+	json.Unmarshal(raw, &parts)
+	return parts
+}
+
+// responsesContentText concatenates the text parts of item content
+func responsesContentText(raw json.RawMessage) string {
+	var sb strings.Builder
+	for _, part := range parseResponsesContent(raw) {
+		switch part.Type {
+		case "input_text", "output_text", "text":
+			sb.WriteString(part.Text)
+		}
+	}
+	return sb.String()
+}
+
+// responsesStreamUsage reads usage from response.completed (or incomplete/failed),
+// the only Responses events that report it
+func responsesStreamUsage(ev Event, usage *StreamUsage) {
+	var event struct {
+		Response *struct {
+			Usage *responsesUsage `json:"usage"`
+		} `json:"response"`
+	}
+	if err := json.Unmarshal(ev.Data, &event); err != nil || event.Response == nil || event.Response.Usage == nil {
+		return
+	}
+	usage.InputTokens = event.Response.Usage.InputTokens
+	usage.OutputTokens = event.Response.Usage.OutputTokens
+	usage.TotalTokens = event.Response.Usage.TotalTokens
+}
@@ -0,0 +1,467 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAI Responses clients served by Chat Completions upstreams
+
+func init() {
+	register(FormatResponses, FormatOpenAI, responsesToOpenAIRequest, openAIToResponsesResponse, newOpenAIToResponsesStream)
+}
+
+// responsesToOpenAIRequest converts a Responses request into a Chat Completions request.
+// Server-side state (previous_response_id, store) and built-in tools can't be carried over.
+func responsesToOpenAIRequest(body []byte, model string, stream bool) ([]byte, error) {
+	var req responsesRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid responses request: %w", err)
+	}
+
+	out := openAIChatRequest{
+		Model:             model,
+		MaxTokens:         req.MaxOutputTokens,
+		Temperature:       req.Temperature,
+		TopP:              req.TopP,
+		Stream:            stream,
+		ParallelToolCalls: req.ParallelToolCalls,
+		User:              req.User,
+	}
+	if stream {
+		out.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+
+	if req.Instructions != "" {
+		out.Messages = append(out.Messages, openAIMessage{Role: "system", Content: jsonString(req.Instructions)})
+	}
+
+	var input string
+	if err := json.Unmarshal(req.Input, &input); err == nil {
+		out.Messages = append(out.Messages, openAIMessage{Role: "user", Content: jsonString(input)})
+	} else {
+		var items []responsesItem
+		if err := json.Unmarshal(req.Input, &items); err != nil {
+			return nil, fmt.Errorf("invalid responses input: %w", err)
+		}
+		out.Messages = append(out.Messages, responsesItemsToOpenAI(items)...)
+	}
+
+	for _, tool := range req.Tools {
+		if tool.Type != "function" {
+			continue
+		}
+		out.Tools = append(out.Tools, openAITool{
+			Type: "function",
+			Function: openAIFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	if len(out.Tools) > 0 && len(req.ToolChoice) > 0 {
+		var named struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(req.ToolChoice, &named); err == nil && named.Type == "function" {
+			out.ToolChoice, _ = json.Marshal(map[string]interface{}{
+				"type":     "function",
+				"function": map[string]string{"name": named.Name},
+			})
+		} else {
+			// "auto", "required" and "none" mean the same in both APIs
+			out.ToolChoice = req.ToolChoice
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// responsesItemsToOpenAI converts input items into chat messages. Function calls
+// join the preceding assistant message so a turn's calls stay together.
+func responsesItemsToOpenAI(items []responsesItem) []openAIMessage {
+	var messages []openAIMessage
+	for _, item := range items {
+		switch item.Type {
+		case "", "message":
+			switch item.Role {
+			case "system", "developer":
+				messages = append(messages, openAIMessage{Role: "system", Content: jsonString(responsesContentText(item.Content))})
+			case "assistant":
+				messages = append(messages, openAIMessage{Role: "assistant", Content: jsonString(responsesContentText(item.Content))})
+			default:
+				messages = append(messages, openAIMessage{Role: "user", Content: responsesPartsToOpenAI(item.Content)})
+			}
+
+		case "function_call":
+			arguments := "{}"
+			if item.Arguments != nil {
+				arguments = *item.Arguments
+			}
+			call := openAIToolCall{
+				ID:       item.CallID,
+				Type:     "function",
+				Function: openAIFunctionCall{Name: item.Name, Arguments: arguments},
+			}
+			if n := len(messages); n > 0 && messages[n-1].Role == "assistant" {
+				messages[n-1].ToolCalls = append(messages[n-1].ToolCalls, call)
+			} else {
+				messages = append(messages, openAIMessage{
+					Role:      "assistant",
+					Content:   json.RawMessage("null"),
+					ToolCalls: []openAIToolCall{call},
+				})
+			}
+
+		case "function_call_output":
+			output := responsesContentText(item.Output)
+			if output == "" {
+				json.Unmarshal(item.Output, &output)
+			}
+			messages = append(messages, openAIMessage{
+				Role:       "tool",
+				ToolCallID: item.CallID,
+				Content:    jsonString(output),
+			})
+		}
+		// reasoning and built-in tool items have no Chat Completions equivalent
+	}
+	return messages
+}
+
+// responsesPartsToOpenAI converts user content into chat content parts
+func responsesPartsToOpenAI(raw json.RawMessage) json.RawMessage {
+	var parts []openAIContentPart
+	for _, part := range parseResponsesContent(raw) {
+		switch part.Type {
+		case "input_text", "output_text", "text":
+			parts = append(parts, openAIContentPart{Type: "text", Text: part.Text})
+		case "input_image":
+			if part.ImageURL != "" {
+				parts = append(parts, openAIContentPart{Type: "image_url", ImageURL: &openAIImageURL{URL: part.ImageURL}})
+			}
+		}
+	}
+	return openAIUserContent(parts)
+}
+
+// openAIToResponsesResponse converts a Chat Completions response into a Responses response
+func openAIToResponsesResponse(body []byte, model string) ([]byte, error) {
+	var resp openAIChatResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("invalid chat completions response: %w", err)
+	}
+
+	id := strings.TrimPrefix(resp.ID, "chatcmpl-")
+	out := responsesResponse{
+		ID:        "resp_" + id,
+		Object:    "response",
+		CreatedAt: resp.Created,
+		Status:    "completed",
+		Model:     model,
+		Output:    []responsesItem{},
+	}
+	if out.CreatedAt == 0 {
+		out.CreatedAt = time.Now().Unix()
+	}
+
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		if choice.Message != nil {
+			if text := openAIContentText(choice.Message.Content); text != "" {
+				out.Output = append(out.Output, responsesMessageItem("msg_"+id, text, "completed"))
+			}
+			for _, call := range choice.Message.ToolCalls {
+				out.Output = append(out.Output, responsesFunctionCallItem(call.ID, call.Function.Name, call.Function.Arguments, "completed"))
+			}
+		}
+		if choice.FinishReason != nil && *choice.FinishReason == "length" {
+			out.Status = "incomplete"
+			out.IncompleteDetails = &responsesIncompleteDetails{Reason: "max_output_tokens"}
+		}
+	}
+
+	if resp.Usage != nil {
+		out.Usage = openAIToResponsesUsage(resp.Usage)
+	}
+	return json.Marshal(out)
+}
+
+// responsesMessageItem builds an assistant message output item
+func responsesMessageItem(id, text, status string) responsesItem {
+	content, _ := json.Marshal([]responsesContent{{Type: "output_text", Text: text}})
+	return responsesItem{
+		Type:    "message",
+		ID:      id,
+		Status:  status,
+		Role:    "assistant",
+		Content: content,
+	}
+}
+
+// responsesFunctionCallItem builds a function_call output item
+func responsesFunctionCallItem(callID, name, arguments, status string) responsesItem {
+	return responsesItem{
+		Type:      "function_call",
+		ID:        "fc_" + callID,
+		Status:    status,
+		CallID:    callID,
+		Name:      name,
+		Arguments: &arguments,
+	}
+}
+
+// openAIToResponsesUsage maps Chat Completions usage onto Responses usage
+func openAIToResponsesUsage(usage *openAIUsage) *responsesUsage {
+	out := &responsesUsage{
+		InputTokens:  usage.PromptTokens,
+		OutputTokens: usage.CompletionTokens,
+		TotalTokens:  usage.TotalTokens,
+	}
+	if out.TotalTokens == 0 {
+		out.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	if usage.PromptTokensDetails != nil {
+		out.InputTokensDetails = &responsesTokenDetails{CachedTokens: usage.PromptTokensDetails.CachedTokens}
+	}
+	return out
+}
+
+// openAIToResponsesStream converts Chat Completions chunks into Responses stream events
+type openAIToResponsesStream struct {
+	model    string
+	id       string
+	created  int64
+	sequence int
+	started  bool
+	done     bool
+
+	output    []responsesItem
+	textIndex int // Output index of the open message item, -1 if none
+	text      strings.Builder
+	tools     map[int]int // Chat tool call index -> output index
+	finish    string
+	usage     *openAIUsage
+}
+
+func newOpenAIToResponsesStream(model string) StreamConverter {
+	return &openAIToResponsesStream{
+		model:     model,
+		created:   time.Now().Unix(),
+		textIndex: -1,
+		tools:     make(map[int]int),
+	}
+}
+
+// event builds a named Responses event with its sequence number
+func (c *openAIToResponsesStream) event(name string, payload map[string]interface{}) Event {
+	payload["type"] = name
+	payload["sequence_number"] = c.sequence
+	c.sequence++
+	data, _ := json.Marshal(payload)
+	return Event{Name: name, Data: data}
+}
+
+// snapshot returns the response object as it stands
+func (c *openAIToResponsesStream) snapshot(status string) responsesResponse {
+	resp := responsesResponse{
+		ID:        c.id,
+		Object:    "response",
+		CreatedAt: c.created,
+		Status:    status,
+		Model:     c.model,
+		Output:    c.output,
+	}
+	if resp.Output == nil {
+		resp.Output = []responsesItem{}
+	}
+	if c.usage != nil {
+		resp.Usage = openAIToResponsesUsage(c.usage)
+	}
+	return resp
+}
+
+func (c *openAIToResponsesStream) start(id string) []Event {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	if id == "" {
+		id = strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	c.id = "resp_" + strings.TrimPrefix(id, "chatcmpl-")
+	return []Event{
+		c.event("response.created", map[string]interface{}{"response": c.snapshot("in_progress")}),
+		c.event("response.in_progress", map[string]interface{}{"response": c.snapshot("in_progress")}),
+	}
+}
+
+func (c *openAIToResponsesStream) Convert(ev Event) []Event {
+	if string(ev.Data) == "[DONE]" {
+		return c.Finish()
+	}
+
+	var chunk struct {
+		openAIChatResponse
+		Error *openAIError `json:"error"`
+	}
+	if err := json.Unmarshal(ev.Data, &chunk); err != nil {
+		return nil
+	}
+	if chunk.Error != nil {
+		return []Event{c.event("error", map[string]interface{}{
+			"code":    chunk.Error.Code,
+			"message": chunk.Error.Message,
+		})}
+	}
+
+	events := c.start(chunk.ID)
+	if chunk.Usage != nil {
+		c.usage = chunk.Usage
+	}
+
+	for _, choice := range chunk.Choices {
+		if delta := choice.Delta; delta != nil {
+			if text := openAIContentText(delta.Content); text != "" {
+				events = append(events, c.textDelta(text)...)
+			}
+			for i, call := range delta.ToolCalls {
+				index := i
+				if call.Index != nil {
+					index = *call.Index
+				}
+				events = append(events, c.toolDelta(index, call)...)
+			}
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			c.finish = *choice.FinishReason
+		}
+	}
+	return events
+}
+
+func (c *openAIToResponsesStream) textDelta(text string) []Event {
+	var events []Event
+	if c.textIndex < 0 {
+		c.textIndex = len(c.output)
+		item := responsesMessageItem("msg_"+strings.TrimPrefix(c.id, "resp_"), "", "in_progress")
+		item.Content = json.RawMessage("[]")
+		c.output = append(c.output, item)
+		events = append(events,
+			c.event("response.output_item.added", map[string]interface{}{
+				"output_index": c.textIndex,
+				"item":         item,
+			}),
+			c.event("response.content_part.added", map[string]interface{}{
+				"item_id":       item.ID,
+				"output_index":  c.textIndex,
+				"content_index": 0,
+				"part":          responsesContent{Type: "output_text"},
+			}),
+		)
+	}
+
+	c.text.WriteString(text)
+	return append(events, c.event("response.output_text.delta", map[string]interface{}{
+		"item_id":       c.output[c.textIndex].ID,
+		"output_index":  c.textIndex,
+		"content_index": 0,
+		"delta":         text,
+	}))
+}
+
+func (c *openAIToResponsesStream) toolDelta(index int, call openAIToolCall) []Event {
+	var events []Event
+	outputIndex, known := c.tools[index]
+	if !known {
+		outputIndex = len(c.output)
+		c.tools[index] = outputIndex
+		callID := call.ID
+		if callID == "" {
+			callID = "call_" + strconv.Itoa(index)
+		}
+		c.output = append(c.output, responsesFunctionCallItem(callID, call.Function.Name, "", "in_progress"))
+		events = append(events, c.event("response.output_item.added", map[string]interface{}{
+			"output_index": outputIndex,
+			"item":         c.output[outputIndex],
+		}))
+	}
+
+	if call.Function.Arguments == "" {
+		return events
+	}
+	item := &c.output[outputIndex]
+	arguments := *item.Arguments + call.Function.Arguments
+	item.Arguments = &arguments
+	return append(events, c.event("response.function_call_arguments.delta", map[string]interface{}{
+		"item_id":      item.ID,
+		"output_index": outputIndex,
+		"delta":        call.Function.Arguments,
+	}))
+}
+
+// Finish completes every open output item and sends the final response with usage
+func (c *openAIToResponsesStream) Finish() []Event {
+	if c.done {
+		return nil
+	}
+	c.done = true
+
+	events := c.start("")
+
+	if c.textIndex >= 0 {
+		text := c.text.String()
+		item := responsesMessageItem(c.output[c.textIndex].ID, text, "completed")
+		c.output[c.textIndex] = item
+		events = append(events,
+			c.event("response.output_text.done", map[string]interface{}{
+				"item_id":       item.ID,
+				"output_index":  c.textIndex,
+				"content_index": 0,
+				"text":          text,
+			}),
+			c.event("response.content_part.done", map[string]interface{}{
+				"item_id":       item.ID,
+				"output_index":  c.textIndex,
+				"content_index": 0,
+				"part":          responsesContent{Type: "output_text", Text: text},
+			}),
+			c.event("response.output_item.done", map[string]interface{}{
+				"output_index": c.textIndex,
+				"item":         item,
+			}),
+		)
+	}
+
+	for outputIndex := range c.output {
+		item := &c.output[outputIndex]
+		if item.Type != "function_call" {
+			continue
+		}
+		item.Status = "completed"
+		events = append(events,
+			c.event("response.function_call_arguments.done", map[string]interface{}{
+				"item_id":      item.ID,
+				"output_index": outputIndex,
+				"arguments":    *item.Arguments,
+			}),
+			c.event("response.output_item.done", map[string]interface{}{
+				"output_index": outputIndex,
+				"item":         *item,
+			}),
+		)
+	}
+
+	if c.finish == "length" {
+		resp := c.snapshot("incomplete")
+		resp.IncompleteDetails = &responsesIncompleteDetails{Reason: "max_output_tokens"}
+		return append(events, c.event("response.incomplete", map[string]interface{}{"response": resp}))
+	}
+	return append(events, c.event("response.completed", map[string]interface{}{"response": c.snapshot("completed")}))
+}
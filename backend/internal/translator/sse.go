@@ -0,0 +1,130 @@
+package translator
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Event is a single server-sent event
+type Event struct {
+	Name string // "event:" field, empty for unnamed events
+	Data []byte
+}
+
+// StreamConverter converts upstream SSE events into events of the client format
+type StreamConverter interface {
+	Convert(ev Event) []Event
+	Finish() []Event // Trailing events once the upstream stream ends
+}
+
+// Encode serializes the event in SSE wire format
+func (e Event) Encode() []byte {
+	var buf bytes.Buffer
+	if e.Name != "" {
+		buf.WriteString("event: ")
+		buf.WriteString(e.Name)
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("data: ")
+	buf.Write(e.Data)
+	buf.WriteString("\n\n")
+	return buf.Bytes()
+}
+
+// ReadEvents parses server-sent events from r and calls fn for each one
+func ReadEvents(r io.Reader, fn func(Event) error) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	var (
+		name string
+		data []byte
+		seen bool
+	)
+	dispatch := func() error {
+		if !seen {
+			return nil
+		}
+		ev := Event{Name: name, Data: data}
+		name, data, seen = "", nil, false
+		return fn(ev)
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			line = bytes.TrimRight(line, "\r\n")
+			switch {
+			case len(line) == 0:
+				// Blank line terminates the event
+				if derr := dispatch(); derr != nil {
+					return derr
+				}
+			case line[0] == ':':
+				// Comment / keep-alive
+			default:
+				field, value, _ := bytes.Cut(line, []byte(":"))
+				value = bytes.TrimPrefix(value, []byte(" "))
+				switch string(field) {
+				case "event":
+					name = string(value)
+					seen = true
+				case "data":
+					if data != nil {
+						data = append(data, '\n')
+					}
+					data = append(data, value...)
+					seen = true
+				}
+			}
+		}
+
+		if err != nil {
+			if derr := dispatch(); derr != nil {
+				return derr
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// streamReader exposes converted events as a body that can replace the upstream one
+type streamReader struct {
+	*io.PipeReader
+	upstream io.ReadCloser
+}
+
+func (r *streamReader) Close() error {
+	r.PipeReader.Close()
+	return r.upstream.Close()
+}
+
+// newStreamReader converts the upstream SSE body event by event as the client reads it
+func newStreamReader(body io.ReadCloser, conv StreamConverter) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		write := func(events []Event) error {
+			for _, ev := range events {
+				if _, err := pw.Write(ev.Encode()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		err := ReadEvents(body, func(ev Event) error {
+			return write(conv.Convert(ev))
+		})
+		if err == nil {
+			err = write(conv.Finish())
+		}
+		body.Close()
+		pw.CloseWithError(err)
+	}()
+
+	return &streamReader{PipeReader: pr, upstream: body}
+}
@@ -0,0 +1,125 @@
+package translator
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Event
+	}{
+		{
+			name:  "unnamed",
+			input: "data: {\"a\":1}\n\ndata: [DONE]\n\n",
+			want:  []Event{{Data: []byte(`{"a":1}`)}, {Data: []byte("[DONE]")}},
+		},
+		{
+			name:  "named",
+			input: "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n",
+			want:  []Event{{Name: "message_stop", Data: []byte(`{"type":"message_stop"}`)}},
+		},
+		{
+			name:  "multi-line data",
+			input: "data: {\"a\":\ndata: 1}\n\n",
+			want:  []Event{{Data: []byte("{\"a\":\n1}")}},
+		},
+		{
+			name:  "crlf",
+			input: "event: ping\r\ndata: {}\r\n\r\n",
+			want:  []Event{{Name: "ping", Data: []byte("{}")}},
+		},
+		{
+			name:  "comments and extra blank lines",
+			input: ": keep-alive\n\n\ndata: {}\n\n: keep-alive\n\n",
+			want:  []Event{{Data: []byte("{}")}},
+		},
+		{
+			name:  "no space after colon",
+			input: "data:{}\n\n",
+			want:  []Event{{Data: []byte("{}")}},
+		},
+		{
+			name:  "name only",
+			input: "event: ping\n\n",
+			want:  []Event{{Name: "ping"}},
+		},
+		{
+			name:  "unknown fields",
+			input: "id: 7\nretry: 1000\ndata: {}\n\n",
+			want:  []Event{{Data: []byte("{}")}},
+		},
+		{
+			name:  "no trailing blank line",
+			input: "data: {\"a\":1}\n\ndata: {\"b\":2}",
+			want:  []Event{{Data: []byte(`{"a":1}`)}, {Data: []byte(`{"b":2}`)}},
+		},
+		{
+			name:  "empty",
+			input: "",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Fed one byte at a time too, so fields split across reads are covered
+			for _, r := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				var got []Event
+				if err := ReadEvents(r, func(ev Event) error {
+					got = append(got, ev)
+					return nil
+				}); err != nil {
+					t.Fatalf("ReadEvents: %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadEventsStopsOnCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := ReadEvents(strings.NewReader("data: 1\n\ndata: 2\n\n"), func(Event) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("got %v after %d calls, want the callback error after 1", err, calls)
+	}
+}
+
+func TestReadEventsReaderError(t *testing.T) {
+	var got []Event
+	err := ReadEvents(iotest.TimeoutReader(strings.NewReader("data: 1\n\n")), func(ev Event) error {
+		got = append(got, ev)
+		return nil
+	})
+	if !errors.Is(err, iotest.ErrTimeout) || len(got) != 1 {
+		t.Errorf("got %v with %d events, want the read error after 1 event", err, len(got))
+	}
+}
+
+func TestEventEncode(t *testing.T) {
+	tests := []struct {
+		ev   Event
+		want string
+	}{
+		{Event{Data: []byte("[DONE]")}, "data: [DONE]\n\n"},
+		{Event{Name: "ping", Data: []byte("{}")}, "event: ping\ndata: {}\n\n"},
+	}
+	for _, tt := range tests {
+		if got := string(tt.ev.Encode()); got != tt.want {
+			t.Errorf("Encode() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package translator
+
+import (
+	"fmt"
+	"io"
+)
+
+// API formats understood by the translator (match providers.APIFormat*)
+const (
+	FormatOpenAI    = "openai"           // OpenAI Chat Completions
+	FormatAnthropic = "anthropic"        // Anthropic Messages
+	FormatGemini    = "gemini"           // Google generateContent
+	FormatResponses = "openai-responses" // OpenAI Responses
+)
+
+// pivotFormat is used to chain translations that have no direct implementation
+const pivotFormat = FormatOpenAI
+
+type requestFunc func(body []byte, model string, stream bool) ([]byte, error)
+type responseFunc func(body []byte, model string) ([]byte, error)
+type streamFunc func(model string) StreamConverter
+
+type formatPair struct {
+	from string
+	to   string
+}
+
+var (
+	requestTranslators  = make(map[formatPair]requestFunc)
+	responseTranslators = make(map[formatPair]responseFunc)
+	streamTranslators   = make(map[formatPair]streamFunc)
+)
+
+// register adds the request, response and stream translations needed for a
+// client speaking `client` to be served by an upstream speaking `upstream`
+func register(client, upstream string, req requestFunc, resp responseFunc, stream streamFunc) {
+	requestTranslators[formatPair{client, upstream}] = req
+	responseTranslators[formatPair{upstream, client}] = resp
+	streamTranslators[formatPair{upstream, client}] = stream
+}
+
+// CanTranslate reports whether a client speaking clientFormat can be served by
+// an upstream speaking upstreamFormat
+func CanTranslate(clientFormat, upstreamFormat string) bool {
+	if clientFormat == upstreamFormat {
+		return true
+	}
+	return requestPath(clientFormat, upstreamFormat) != nil
+}
+
+// TranslateRequest converts a request body from the client format to the upstream format
+func TranslateRequest(from, to string, body []byte, model string, stream bool) ([]byte, error) {
+	if from == to {
+		return body, nil
+	}
+
+	steps := requestPath(from, to)
+	if steps == nil {
+		return nil, fmt.Errorf("no request translation from %s to %s", from, to)
+	}
+
+	var err error
+	for _, step := range steps {
+		if body, err = step(body, model, stream); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// TranslateResponse converts a non-streaming response body from the upstream format to the client format
+func TranslateResponse(from, to string, body []byte, model string) ([]byte, error) {
+	if from == to {
+		return body, nil
+	}
+
+	var steps []responseFunc
+	if fn, ok := responseTranslators[formatPair{from, to}]; ok {
+		steps = []responseFunc{fn}
+	} else {
+		first, ok1 := responseTranslators[formatPair{from, pivotFormat}]
+		second, ok2 := responseTranslators[formatPair{pivotFormat, to}]
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("no response translation from %s to %s", from, to)
+		}
+		steps = []responseFunc{first, second}
+	}
+
+	var err error
+	for _, step := range steps {
+		if body, err = step(body, model); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// TranslateStream wraps an upstream SSE body so the client receives events in its own format
+func TranslateStream(from, to string, body io.ReadCloser, model string) io.ReadCloser {
+	if from == to {
+		return body
+	}
+
+	if fn, ok := streamTranslators[formatPair{from, to}]; ok {
+		return newStreamReader(body, fn(model))
+	}
+
+	first, ok1 := streamTranslators[formatPair{from, pivotFormat}]
+	second, ok2 := streamTranslators[formatPair{pivotFormat, to}]
+	if !ok1 || !ok2 {
+		return body
+	}
+	return newStreamReader(newStreamReader(body, first(model)), second(model))
+}
+
+// requestPath returns the request translation steps from one format to another,
+// going through the pivot format when there's no direct translation
+func requestPath(from, to string) []requestFunc {
+	if fn, ok := requestTranslators[formatPair{from, to}]; ok {
+		return []requestFunc{fn}
+	}
+
+	first, ok1 := requestTranslators[formatPair{from, pivotFormat}]
+	second, ok2 := requestTranslators[formatPair{pivotFormat, to}]
+	if ok1 && ok2 {
+		return []requestFunc{first, second}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// update rewrites the golden files from the current output:
+//
+//	go test ./internal/translator -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// volatileFields are set from the clock and left out of golden comparisons
+var volatileFields = map[string]bool{"created": true, "created_at": true}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// normalizeJSON decodes data with the volatile fields removed, so documents can
+// be compared regardless of key order and formatting. Non-JSON data such as the
+// [DONE] marker is returned as a string.
+func normalizeJSON(data []byte) interface{} {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return string(data)
+	}
+	return stripVolatile(value)
+}
+
+func stripVolatile(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if volatileFields[key] {
+				delete(v, key)
+				continue
+			}
+			v[key] = stripVolatile(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stripVolatile(item)
+		}
+	}
+	return value
+}
+
+func indentJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return data
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// checkGoldenJSON compares a JSON document with the golden file, or rewrites it with -update
+func checkGoldenJSON(t *testing.T, golden string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(path, indentJSON(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want := readFixture(t, golden)
+	if !reflect.DeepEqual(normalizeJSON(got), normalizeJSON(want)) {
+		t.Errorf("output differs from %s\ngot:\n%s\nwant:\n%s", golden, indentJSON(got), want)
+	}
+}
+
+func TestTranslateRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   string
+		to     string
+		model  string
+		stream bool
+	}{
+		{"openai_to_claude", FormatOpenAI, FormatAnthropic, "claude-3-5-sonnet-20241022", true},
+		{"openai_to_claude_minimal", FormatOpenAI, FormatAnthropic, "claude-3-haiku-20240307", false},
+		{"claude_to_openai", FormatAnthropic, FormatOpenAI, "gpt-4o", true},
+		{"responses_to_openai", FormatResponses, FormatOpenAI, "gpt-4o", true},
+		{"responses_to_claude", FormatResponses, FormatAnthropic, "claude-3-5-sonnet-20241022", false},
+		{"openai_to_gemini", FormatOpenAI, FormatGemini, "gemini-2.0-flash", true},
+		{"openai_to_gemini_tool_choice_none", FormatOpenAI, FormatGemini, "gemini-2.0-flash", false},
+		{"gemini_to_openai", FormatGemini, FormatOpenAI, "gpt-4o", true},
+		{"gemini_to_openai_minimal", FormatGemini, FormatOpenAI, "gpt-4o", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := readFixture(t, "request_"+tt.name+".input.json")
+			got, err := TranslateRequest(tt.from, tt.to, input, tt.model, tt.stream)
+			if err != nil {
+				t.Fatalf("TranslateRequest: %v", err)
+			}
+			checkGoldenJSON(t, "request_"+tt.name+".golden.json", got)
+		})
+	}
+}
+
+func TestTranslateRequestInvalid(t *testing.T) {
+	for _, pair := range [][2]string{
+		{FormatOpenAI, FormatAnthropic},
+		{FormatAnthropic, FormatOpenAI},
+		{FormatResponses, FormatOpenAI},
+		{FormatOpenAI, FormatGemini},
+		{FormatGemini, FormatOpenAI},
+	} {
+		if _, err := TranslateRequest(pair[0], pair[1], []byte(`{"messages":`), "m", false); err == nil {
+			t.Errorf("%s -> %s: expected an error for a malformed body", pair[0], pair[1])
+		}
+	}
+}
+
+func TestTranslateResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		model string
+	}{
+		{"claude_to_openai", FormatAnthropic, FormatOpenAI, "claude-3-5-sonnet-20241022"},
+		{"claude_to_openai_tools_only", FormatAnthropic, FormatOpenAI, "claude-3-5-sonnet-20241022"},
+		{"openai_to_claude", FormatOpenAI, FormatAnthropic, "gpt-4o"},
+		{"openai_to_responses", FormatOpenAI, FormatResponses, "gpt-4o"},
+		{"claude_to_responses", FormatAnthropic, FormatResponses, "claude-3-5-sonnet-20241022"},
+		{"gemini_to_openai", FormatGemini, FormatOpenAI, "gemini-2.0-flash"},
+		{"gemini_to_openai_max_tokens", FormatGemini, FormatOpenAI, "gemini-2.0-flash"},
+		{"openai_to_gemini", FormatOpenAI, FormatGemini, "gpt-4o"},
+		{"openai_to_gemini_filtered", FormatOpenAI, FormatGemini, "gpt-4o"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := readFixture(t, "response_"+tt.name+".input.json")
+			got, err := TranslateResponse(tt.from, tt.to, input, tt.model)
+			if err != nil {
+				t.Fatalf("TranslateResponse: %v", err)
+			}
+			checkGoldenJSON(t, "response_"+tt.name+".golden.json", got)
+		})
+	}
+}
+
+func TestTranslateSameFormat(t *testing.T) {
+	body := []byte(`{"model":"gpt-4o","messages":[]}`)
+	if got, err := TranslateRequest(FormatOpenAI, FormatOpenAI, body, "other", false); err != nil || !bytes.Equal(got, body) {
+		t.Errorf("TranslateRequest changed a same-format body: %s, %v", got, err)
+	}
+	if got, err := TranslateResponse(FormatOpenAI, FormatOpenAI, body, "other"); err != nil || !bytes.Equal(got, body) {
+		t.Errorf("TranslateResponse changed a same-format body: %s, %v", got, err)
+	}
+}
+
+// readStream collects the events of a translated stream
+func readStream(t *testing.T, body io.ReadCloser) []Event {
+	t.Helper()
+	defer body.Close()
+
+	var events []Event
+	if err := ReadEvents(body, func(ev Event) error {
+		events = append(events, ev)
+		return nil
+	}); err != nil {
+		t.Fatalf("reading translated stream: %v", err)
+	}
+	return events
+}
+
+func encodeEvents(events []Event) []byte {
+	var buf bytes.Buffer
+	for _, ev := range events {
+		buf.Write(ev.Encode())
+	}
+	return buf.Bytes()
+}
+
+// checkGoldenStream compares events with a golden SSE file, or rewrites it with -update
+func checkGoldenStream(t *testing.T, golden string, got []Event) {
+	t.Helper()
+	path := filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(path, encodeEvents(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	var want []Event
+	if err := ReadEvents(bytes.NewReader(readFixture(t, golden)), func(ev Event) error {
+		want = append(want, ev)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d from %s\ngot:\n%s", len(got), len(want), golden, encodeEvents(got))
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || !reflect.DeepEqual(normalizeJSON(got[i].Data), normalizeJSON(want[i].Data)) {
+			t.Errorf("event %d differs from %s\ngot:  %s%s\nwant: %s%s", i, golden,
+				got[i].Name, got[i].Data, want[i].Name, want[i].Data)
+		}
+	}
+}
+
+func TestTranslateStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  string
+		to    string
+		model string
+	}{
+		{"claude_to_openai", FormatAnthropic, FormatOpenAI, "claude-3-5-sonnet-20241022"},
+		{"openai_to_claude", FormatOpenAI, FormatAnthropic, "gpt-4o"},
+		{"openai_to_claude_no_done", FormatOpenAI, FormatAnthropic, "gpt-4o"},
+		{"openai_to_responses", FormatOpenAI, FormatResponses, "gpt-4o"},
+		{"openai_to_responses_length", FormatOpenAI, FormatResponses, "gpt-4o"},
+		{"gemini_to_openai", FormatGemini, FormatOpenAI, "gemini-2.0-flash"},
+		{"gemini_to_openai_max_tokens", FormatGemini, FormatOpenAI, "gemini-2.0-flash"},
+		{"openai_to_gemini", FormatOpenAI, FormatGemini, "gpt-4o"},
+		{"openai_to_gemini_no_done", FormatOpenAI, FormatGemini, "gpt-4o"},
+	}
+
+	// Every stream is also fed one byte at a time, so events split across reads are covered
+	readers := []struct {
+		name string
+		wrap func(io.Reader) io.Reader
+	}{
+		{"whole", func(r io.Reader) io.Reader { return r }},
+		{"split", iotest.OneByteReader},
+	}
+
+	for _, tt := range tests {
+		for _, reader := range readers {
+			t.Run(tt.name+"/"+reader.name, func(t *testing.T) {
+				if *update && reader.name != "whole" {
+					t.Skip("golden files are written from whole reads")
+				}
+				input := readFixture(t, "stream_"+tt.name+".input.sse")
+				body := io.NopCloser(reader.wrap(bytes.NewReader(input)))
+				got := readStream(t, TranslateStream(tt.from, tt.to, body, tt.model))
+				checkGoldenStream(t, "stream_"+tt.name+".golden.sse", got)
+			})
+		}
+	}
+}
+
+func TestCanTranslate(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{FormatOpenAI, FormatOpenAI, true},
+		{FormatOpenAI, FormatAnthropic, true},
+		{FormatAnthropic, FormatOpenAI, true},
+		{FormatResponses, FormatAnthropic, true}, // Through the OpenAI pivot
+		{FormatGemini, FormatOpenAI, true},
+		{FormatOpenAI, FormatGemini, true},
+		{FormatOpenAI, "unknown", false},
+	}
+	for _, tt := range tests {
+		if got := CanTranslate(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTranslate(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTranslateStreamUnknownPairPassesThrough(t *testing.T) {
+	input := "data: {\"x\":1}\n\n"
+	body := TranslateStream(FormatOpenAI, "unknown", io.NopCloser(strings.NewReader(input)), "m")
+	data, _ := io.ReadAll(body)
+	if string(data) != input {
+		t.Errorf("got %q, want the upstream body unchanged", data)
+	}
+}
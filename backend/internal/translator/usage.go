@@ -0,0 +1,65 @@
+package translator
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// StreamUsage accumulates the token usage an upstream reports inside an SSE stream
+type StreamUsage struct {
+	InputTokens  int64
+	OutputTokens int64
+	TotalTokens  int64 // Only set when the upstream reports a total
+}
+
+// Total returns the reported total, or input plus output when there isn't one
+func (u StreamUsage) Total() int64 {
+	if u.TotalTokens > 0 {
+		return u.TotalTokens
+	}
+	return u.InputTokens + u.OutputTokens
+}
+
+// usageParsers update usage from a single stream event of their format
+var usageParsers = map[string]func(ev Event, usage *StreamUsage){
+	FormatAnthropic: claudeStreamUsage,
+	FormatOpenAI:    openAIStreamUsage,
+	FormatGemini:    geminiStreamUsage,
+	FormatResponses: responsesStreamUsage,
+}
+
+// CanMeterStream reports whether usage can be read from streams of a format
+func CanMeterStream(format string) bool {
+	_, ok := usageParsers[format]
+	return ok
+}
+
+// Observe updates usage from one event of a stream in the given format
+func (u *StreamUsage) Observe(format string, ev Event) {
+	if parse, ok := usageParsers[format]; ok {
+		parse(ev, u)
+	}
+}
+
+// DropUsageChunks removes the usage-only chunks from an OpenAI Chat Completions
+// stream, for clients that didn't ask for stream_options.include_usage
+func DropUsageChunks(body io.ReadCloser) io.ReadCloser {
+	return newStreamReader(body, usageChunkFilter{})
+}
+
+type usageChunkFilter struct{}
+
+func (usageChunkFilter) Convert(ev Event) []Event {
+	var chunk struct {
+		Choices []json.RawMessage `json:"choices"`
+		Usage   *openAIUsage      `json:"usage"`
+	}
+	if err := json.Unmarshal(ev.Data, &chunk); err == nil && chunk.Usage != nil && len(chunk.Choices) == 0 {
+		return nil
+	}
+	return []Event{ev}
+}
+
+func (usageChunkFilter) Finish() []Event {
+	return nil
+}
@@ -0,0 +1,127 @@
+package translator
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamUsageObserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		events []Event
+		want   StreamUsage
+	}{
+		{
+			name:   "responses completed",
+			format: FormatResponses,
+			events: []Event{
+				{Name: "response.created", Data: []byte(`{"type":"response.created","response":{"id":"resp_1","status":"in_progress","usage":null}}`)},
+				{Name: "response.output_text.delta", Data: []byte(`{"type":"response.output_text.delta","delta":"Hi"}`)},
+				{Name: "response.completed", Data: []byte(`{"type":"response.completed","response":{"id":"resp_1","status":"completed","usage":{"input_tokens":31,"output_tokens":7,"total_tokens":38}}}`)},
+			},
+			want: StreamUsage{InputTokens: 31, OutputTokens: 7, TotalTokens: 38},
+		},
+		{
+			name:   "responses incomplete",
+			format: FormatResponses,
+			events: []Event{
+				{Name: "response.created", Data: []byte(`{"type":"response.created","response":{"id":"resp_2","status":"in_progress"}}`)},
+				{Name: "response.incomplete", Data: []byte(`{"type":"response.incomplete","response":{"id":"resp_2","status":"incomplete","usage":{"input_tokens":9,"output_tokens":16,"total_tokens":25}}}`)},
+			},
+			want: StreamUsage{InputTokens: 9, OutputTokens: 16, TotalTokens: 25},
+		},
+		{
+			name:   "responses without usage",
+			format: FormatResponses,
+			events: []Event{
+				{Name: "response.output_text.delta", Data: []byte(`{"type":"response.output_text.delta","delta":"Hi"}`)},
+			},
+			want: StreamUsage{},
+		},
+		{
+			name:   "claude with cache tokens",
+			format: FormatAnthropic,
+			events: []Event{
+				{Name: "message_start", Data: []byte(`{"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":20,"cache_creation_input_tokens":100,"cache_read_input_tokens":300,"output_tokens":1}}}`)},
+				{Name: "content_block_delta", Data: []byte(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`)},
+				{Name: "message_delta", Data: []byte(`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":42}}`)},
+				{Name: "message_stop", Data: []byte(`{"type":"message_stop"}`)},
+			},
+			want: StreamUsage{InputTokens: 420, OutputTokens: 42},
+		},
+		{
+			name:   "openai usage chunk",
+			format: FormatOpenAI,
+			events: []Event{
+				{Data: []byte(`{"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"}}]}`)},
+				{Data: []byte(`{"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)},
+				{Data: []byte(`{"id":"c1","choices":[],"usage":{"prompt_tokens":11,"completion_tokens":2,"total_tokens":13}}`)},
+				{Data: []byte("[DONE]")},
+			},
+			want: StreamUsage{InputTokens: 11, OutputTokens: 2, TotalTokens: 13},
+		},
+		{
+			name:   "gemini with thoughts",
+			format: FormatGemini,
+			events: []Event{
+				{Data: []byte(`{"candidates":[{"content":{"parts":[{"text":"Hi"}]}}],"usageMetadata":{"promptTokenCount":8}}`)},
+				{Data: []byte(`{"candidates":[{"content":{"parts":[{"text":"!"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":8,"candidatesTokenCount":3,"thoughtsTokenCount":5,"totalTokenCount":16}}`)},
+			},
+			want: StreamUsage{InputTokens: 8, OutputTokens: 8, TotalTokens: 16},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var usage StreamUsage
+			for _, ev := range tt.events {
+				usage.Observe(tt.format, ev)
+			}
+			if usage != tt.want {
+				t.Errorf("got %+v, want %+v", usage, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamUsageTotal(t *testing.T) {
+	if got := (StreamUsage{InputTokens: 3, OutputTokens: 4}).Total(); got != 7 {
+		t.Errorf("Total() = %d, want the sum 7 without a reported total", got)
+	}
+	if got := (StreamUsage{InputTokens: 3, OutputTokens: 4, TotalTokens: 10}).Total(); got != 10 {
+		t.Errorf("Total() = %d, want the reported total 10", got)
+	}
+}
+
+func TestCanMeterStream(t *testing.T) {
+	for _, format := range []string{FormatOpenAI, FormatAnthropic, FormatGemini, FormatResponses} {
+		if !CanMeterStream(format) {
+			t.Errorf("CanMeterStream(%s) = false, want true", format)
+		}
+	}
+	if CanMeterStream("unknown") {
+		t.Error("CanMeterStream(unknown) = true, want false")
+	}
+}
+
+func TestDropUsageChunks(t *testing.T) {
+	input := "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":1,\"total_tokens\":2}}\n\n" +
+		"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":1,\"total_tokens\":2}}\n\n" +
+		"data: [DONE]\n\n"
+	want := "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+		"data: {\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}],\"usage\":{\"prompt_tokens\":1,\"completion_tokens\":1,\"total_tokens\":2}}\n\n" +
+		"data: [DONE]\n\n"
+
+	body := DropUsageChunks(io.NopCloser(strings.NewReader(input)))
+	defer body.Close()
+	got, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant only the usage-only chunk removed:\n%s", got, want)
+	}
+}